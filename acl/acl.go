@@ -0,0 +1,330 @@
+// Access control lists. An ACL holds the users which clients can
+// authenticate as, and checks the commands, keys and pub/sub channels
+// each user is allowed to access.
+
+package acl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Devansh3712/tandb/resp"
+)
+
+const (
+	DefaultUser = "default"
+	// Maximum number of entries kept in the ACL log
+	MaxLogLen = 128
+	// Denials with the same reason, object and user within this
+	// interval are grouped into one log entry
+	logGroupInterval = 60 * time.Second
+)
+
+var (
+	ErrUserNotExists  = errors.New("the user does not exist")
+	ErrDeleteDefault  = errors.New("The 'default' user cannot be removed")
+	ErrNoACLFile      = errors.New("This instance is not configured to use an ACL file. You may want to specify users via the ACL SETUSER command and then issue a CONFIG REWRITE in order to store users in the configuration.")
+	ErrNoPermCommand  = errors.New("NOPERM User has no permissions to run this command")
+	ErrNoPermKey      = errors.New("NOPERM No permissions to access a key")
+	ErrNoPermChannel  = errors.New("NOPERM No permissions to access a channel")
+	ErrInvalidACLLine = errors.New("invalid ACL file line")
+)
+
+// Reasons of ACL log entries.
+const (
+	ReasonAuth    = "auth"
+	ReasonCommand = "command"
+	ReasonKey     = "key"
+	ReasonChannel = "channel"
+)
+
+type LogEntry struct {
+	ID         int64
+	Count      int
+	Reason     string
+	Context    string
+	Object     string
+	Username   string
+	ClientInfo string
+	Created    time.Time
+	Updated    time.Time
+}
+
+type ACL struct {
+	Mutex *sync.RWMutex
+	Users map[string]*User
+	// Entries of the ACL log, newest first
+	Log []*LogEntry
+	// Reports whether a command exists, used to validate rules
+	CommandExists func(name string) bool
+
+	nextLogID int64
+}
+
+// Create an ACL with the default user, which is enabled, has no
+// password and may run every command on every key and channel.
+func NewACL(commandExists func(string) bool) *ACL {
+	acl := &ACL{
+		Mutex:         &sync.RWMutex{},
+		Users:         make(map[string]*User),
+		CommandExists: commandExists,
+	}
+	acl.Users[DefaultUser] = newDefaultUser()
+	return acl
+}
+
+func newDefaultUser() *User {
+	user := NewUser(DefaultUser)
+	for _, rule := range []string{"on", "nopass", "allkeys", "allchannels", "allcommands"} {
+		user.ApplyRule(rule, nil)
+	}
+	return user
+}
+
+// Create or modify a user by applying the rules in order. The user
+// is only modified if every rule is valid.
+func (a *ACL) SetUser(name string, rules []string) error {
+	a.Mutex.Lock()
+	defer a.Mutex.Unlock()
+
+	user := NewUser(name)
+	if existing, ok := a.Users[name]; ok {
+		user = existing.clone()
+	}
+	for _, rule := range rules {
+		if err := user.ApplyRule(rule, a.CommandExists); err != nil {
+			return fmt.Errorf("Error in ACL SETUSER modifier '%s': %s", rule, err.Error())
+		}
+	}
+	a.Users[name] = user
+	return nil
+}
+
+// Returns a copy of a user, which is safe to read without the lock.
+func (a *ACL) GetUser(name string) (*User, bool) {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+
+	user, ok := a.Users[name]
+	if !ok {
+		return nil, false
+	}
+	return user.clone(), true
+}
+
+// Delete a user, returning false if the user did not exist.
+func (a *ACL) DelUser(name string) (bool, error) {
+	if name == DefaultUser {
+		return false, ErrDeleteDefault
+	}
+	a.Mutex.Lock()
+	defer a.Mutex.Unlock()
+
+	if _, ok := a.Users[name]; !ok {
+		return false, nil
+	}
+	delete(a.Users, name)
+	return true, nil
+}
+
+// Returns the descriptions of every user, sorted by name.
+func (a *ACL) List() []string {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+
+	var users []string
+	for _, user := range a.Users {
+		users = append(users, user.String())
+	}
+	sort.Strings(users)
+	return users
+}
+
+// Check the credentials of a user.
+func (a *ACL) Authenticate(name, password string) bool {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+
+	user, ok := a.Users[name]
+	return ok && user.Authenticate(password)
+}
+
+// Check if a new connection is authenticated as the default user
+// without running AUTH, which is the case while the default user is
+// enabled and has no password.
+func (a *ACL) DefaultAuthenticated() bool {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+
+	user, ok := a.Users[DefaultUser]
+	return ok && user.Enabled && user.NoPass
+}
+
+// Check if a user may run a command. The keys of the command must be
+// accessible with the given permissions. Disabling a user only stops
+// new authentications, so clients which already authenticated keep
+// their permissions. On failure the denial is recorded in the ACL log,
+// with the client described by clientInfo, which is only called then.
+func (a *ACL) Check(name, command string, categories, keys []string, perm int, clientInfo func() string) error {
+	a.Mutex.RLock()
+	user, ok := a.Users[name]
+	var reason, object string
+	var err error
+	switch {
+	case !ok || !user.CanRun(command, categories):
+		reason, object, err = ReasonCommand, strings.ToLower(command), ErrNoPermCommand
+	default:
+		for _, key := range keys {
+			if !user.CanAccessKey(key, perm) {
+				reason, object, err = ReasonKey, key, ErrNoPermKey
+				break
+			}
+		}
+	}
+	a.Mutex.RUnlock()
+
+	if err != nil {
+		a.AddLogEntry(reason, object, name, clientInfo())
+	}
+	return err
+}
+
+// Check if a user may access a pub/sub channel.
+func (a *ACL) CheckChannel(name, channel, clientInfo string) error {
+	a.Mutex.RLock()
+	user, ok := a.Users[name]
+	allowed := ok && user.CanAccessChannel(channel)
+	a.Mutex.RUnlock()
+
+	if !allowed {
+		a.AddLogEntry(ReasonChannel, channel, name, clientInfo)
+		return ErrNoPermChannel
+	}
+	return nil
+}
+
+// Record a denied command or a failed authentication. Denials matching
+// a recent entry increase its count instead of adding a new entry.
+func (a *ACL) AddLogEntry(reason, object, username, clientInfo string) {
+	a.Mutex.Lock()
+	defer a.Mutex.Unlock()
+
+	now := time.Now()
+	for _, entry := range a.Log {
+		if entry.Reason == reason && entry.Object == object && entry.Username == username &&
+			now.Sub(entry.Updated) < logGroupInterval {
+			entry.Count++
+			entry.Updated = now
+			entry.ClientInfo = clientInfo
+			return
+		}
+	}
+
+	a.nextLogID++
+	entry := &LogEntry{
+		ID: a.nextLogID, Count: 1, Reason: reason, Context: "toplevel",
+		Object: object, Username: username, ClientInfo: clientInfo,
+		Created: now, Updated: now,
+	}
+	a.Log = append([]*LogEntry{entry}, a.Log...)
+	if len(a.Log) > MaxLogLen {
+		a.Log = a.Log[:MaxLogLen]
+	}
+}
+
+// Returns copies of the newest count entries of the ACL log.
+func (a *ACL) LogEntries(count int) []LogEntry {
+	a.Mutex.RLock()
+	defer a.Mutex.RUnlock()
+
+	if count < 0 || count > len(a.Log) {
+		count = len(a.Log)
+	}
+	entries := make([]LogEntry, count)
+	for i := range entries {
+		entries[i] = *a.Log[i]
+	}
+	return entries
+}
+
+func (a *ACL) ResetLog() {
+	a.Mutex.Lock()
+	defer a.Mutex.Unlock()
+
+	a.Log = nil
+}
+
+// Write every user to the ACL file, replacing its contents. Passwords
+// are only written as hashes.
+func (a *ACL) Save(path string) error {
+	if path == "" {
+		return ErrNoACLFile
+	}
+	users := a.List()
+
+	// Write to a temporary file first, so a failed save does not
+	// leave a truncated ACL file behind
+	temp := path + ".tmp"
+	content := strings.Join(users, "\n") + "\n"
+	if err := os.WriteFile(temp, []byte(content), 0600); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// Replace every user with the users of the ACL file. Each line of the
+// file has the format "user <name> <rule> ...", and empty lines or
+// lines starting with '#' are ignored. If any line is invalid, no user
+// is changed. The default user is created if the file does not
+// define it.
+func (a *ACL) Load(path string) error {
+	if path == "" {
+		return ErrNoACLFile
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	users := make(map[string]*User)
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields, err := resp.SplitArgs(line)
+		if err != nil || len(fields) < 2 || fields[0] != "user" {
+			return fmt.Errorf("%s:%d: %w", path, lineNo, ErrInvalidACLLine)
+		}
+		if _, ok := users[fields[1]]; ok {
+			return fmt.Errorf("%s:%d: duplicate user '%s'", path, lineNo, fields[1])
+		}
+		user := NewUser(fields[1])
+		for _, rule := range fields[2:] {
+			if err := user.ApplyRule(rule, a.CommandExists); err != nil {
+				return fmt.Errorf("%s:%d: %s: %s", path, lineNo, rule, err.Error())
+			}
+		}
+		users[user.Name] = user
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if _, ok := users[DefaultUser]; !ok {
+		users[DefaultUser] = newDefaultUser()
+	}
+
+	a.Mutex.Lock()
+	defer a.Mutex.Unlock()
+
+	a.Users = users
+	return nil
+}
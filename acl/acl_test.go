@@ -0,0 +1,92 @@
+package acl
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func createTestACL(t *testing.T) *ACL {
+	t.Helper()
+	acl := NewACL(nil)
+	rules := []string{"on", ">secret", "%R~cache:*", "~session:*", "-@all", "+@read", "-keys"}
+	if err := acl.SetUser("alice", rules); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	return acl
+}
+
+func TestAuthenticate(t *testing.T) {
+	acl := createTestACL(t)
+
+	if !acl.Authenticate("alice", "secret") {
+		t.Errorf("got false, wanted true")
+	}
+	if acl.Authenticate("alice", "wrong") {
+		t.Errorf("got true, wanted false")
+	}
+	if !acl.DefaultAuthenticated() {
+		t.Errorf("got false, wanted true")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	acl := createTestACL(t)
+	read := []string{"string", "read"}
+
+	tests := []struct {
+		command    string
+		categories []string
+		keys       []string
+		perm       int
+		want       error
+	}{
+		{"GET", read, []string{"cache:1"}, PermRead, nil},
+		{"GET", read, []string{"other"}, PermRead, ErrNoPermKey},
+		{"SET", []string{"string", "write"}, []string{"cache:1"}, PermWrite, ErrNoPermCommand},
+		{"KEYS", []string{"keyspace", "read"}, nil, PermRead, ErrNoPermCommand},
+	}
+	// The client is only described when a command is denied
+	described := 0
+	clientInfo := func() string {
+		described++
+		return "id=1"
+	}
+	for _, test := range tests {
+		got := acl.Check("alice", test.command, test.categories, test.keys, test.perm, clientInfo)
+		if got != test.want {
+			t.Errorf("%s: got %v, wanted %v", test.command, got, test.want)
+		}
+	}
+
+	if got := len(acl.LogEntries(-1)); got != 3 {
+		t.Errorf("got %d, wanted %d", got, 3)
+	}
+	if described != 3 {
+		t.Errorf("got %d, wanted %d", described, 3)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	acl := createTestACL(t)
+	path := filepath.Join(t.TempDir(), "users.acl")
+	if err := acl.Save(path); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+
+	loaded := NewACL(nil)
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	got, want := loaded.List(), acl.List()
+	if len(got) != len(want) {
+		t.Fatalf("got %q, wanted %q", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("got %q, wanted %q", got[i], want[i])
+		}
+	}
+	if !loaded.Authenticate("alice", "secret") {
+		t.Errorf("got false, wanted true")
+	}
+}
@@ -0,0 +1,368 @@
+package acl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Devansh3712/tandb/glob"
+)
+
+// Categories of commands which can be used in ACL rules.
+var Categories = []string{
+	"keyspace", "read", "write", "set", "sortedset", "string", "bitmap",
+	"pubsub", "admin", "fast", "slow", "blocking", "dangerous", "connection",
+}
+
+// Permissions of a key pattern.
+const (
+	PermRead = 1 << iota
+	PermWrite
+	PermAll = PermRead | PermWrite
+)
+
+type KeyPattern struct {
+	Pattern string
+	Perm    int
+}
+
+// A command rule allows or denies either a single command or
+// a category of commands, such as "+get" or "-@dangerous".
+type CommandRule struct {
+	Allow    bool
+	Name     string
+	Category string
+}
+
+func (r CommandRule) String() string {
+	prefix := "-"
+	if r.Allow {
+		prefix = "+"
+	}
+	if r.Category != "" {
+		return prefix + "@" + r.Category
+	}
+	return prefix + strings.ToLower(r.Name)
+}
+
+func (r CommandRule) matches(name string, categories []string) bool {
+	if r.Category == "" {
+		return r.Name == name
+	}
+	if r.Category == "all" {
+		return true
+	}
+	for _, category := range categories {
+		if category == r.Category {
+			return true
+		}
+	}
+	return false
+}
+
+// User holds the credentials and permissions of an ACL user. Passwords
+// are only kept as SHA-256 hashes. Users are not safe for concurrent
+// use, and are guarded by the mutex of the ACL they belong to.
+type User struct {
+	Name      string
+	Enabled   bool
+	NoPass    bool
+	Passwords map[string]struct{}
+	Commands  []CommandRule
+	Keys      []KeyPattern
+	Channels  []string
+}
+
+func NewUser(name string) *User {
+	return &User{Name: name, Passwords: make(map[string]struct{})}
+}
+
+func HashPassword(password string) string {
+	hash := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(hash[:])
+}
+
+func (u *User) clone() *User {
+	user := *u
+	user.Passwords = make(map[string]struct{}, len(u.Passwords))
+	for hash := range u.Passwords {
+		user.Passwords[hash] = struct{}{}
+	}
+	user.Commands = append([]CommandRule(nil), u.Commands...)
+	user.Keys = append([]KeyPattern(nil), u.Keys...)
+	user.Channels = append([]string(nil), u.Channels...)
+	return &user
+}
+
+// Check if the password is valid for the user.
+func (u *User) Authenticate(password string) bool {
+	if !u.Enabled {
+		return false
+	}
+	if u.NoPass {
+		return true
+	}
+	_, ok := u.Passwords[HashPassword(password)]
+	return ok
+}
+
+// Check if the user may run a command belonging to the categories.
+// Rules are applied in order, so the last matching rule wins.
+func (u *User) CanRun(name string, categories []string) bool {
+	allowed := false
+	for _, rule := range u.Commands {
+		if rule.matches(name, categories) {
+			allowed = rule.Allow
+		}
+	}
+	return allowed
+}
+
+// Check if the user may access a key with the given permissions.
+func (u *User) CanAccessKey(key string, perm int) bool {
+	for _, pattern := range u.Keys {
+		if pattern.Perm&perm == perm && glob.Match(pattern.Pattern, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check if the user may access a pub/sub channel.
+func (u *User) CanAccessChannel(channel string) bool {
+	for _, pattern := range u.Channels {
+		if glob.Match(pattern, channel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply a single rule to the user. The rules follow the syntax of
+// ACL SETUSER:
+//
+//	on, off                        enable or disable the user
+//	>password, <password           add or remove a password
+//	#hash, !hash                   add or remove a SHA-256 password hash
+//	nopass, resetpass              allow any password, or remove all
+//	~pattern, %R~, %W~, %RW~       allow keys matching the pattern
+//	allkeys, resetkeys             allow all keys, or remove the patterns
+//	&pattern                       allow channels matching the pattern
+//	allchannels, resetchannels     allow all channels, or remove the patterns
+//	+command, -command             allow or deny a command
+//	+@category, -@category         allow or deny a category of commands
+//	allcommands, nocommands        aliases of +@all and -@all
+//	reset                          remove every permission and password
+//
+// The commandExists function is used to validate command names.
+func (u *User) ApplyRule(rule string, commandExists func(string) bool) error {
+	switch strings.ToLower(rule) {
+	case "on":
+		u.Enabled = true
+		return nil
+	case "off":
+		u.Enabled = false
+		return nil
+	case "nopass":
+		u.NoPass = true
+		u.Passwords = make(map[string]struct{})
+		return nil
+	case "resetpass":
+		u.NoPass = false
+		u.Passwords = make(map[string]struct{})
+		return nil
+	case "allkeys":
+		u.Keys = []KeyPattern{{Pattern: "*", Perm: PermAll}}
+		return nil
+	case "resetkeys":
+		u.Keys = nil
+		return nil
+	case "allchannels":
+		u.Channels = []string{"*"}
+		return nil
+	case "resetchannels":
+		u.Channels = nil
+		return nil
+	case "allcommands":
+		u.Commands = []CommandRule{{Allow: true, Category: "all"}}
+		return nil
+	case "nocommands":
+		u.Commands = []CommandRule{{Allow: false, Category: "all"}}
+		return nil
+	case "reset":
+		*u = User{Name: u.Name, Passwords: make(map[string]struct{})}
+		return nil
+	}
+	if rule == "" {
+		return fmt.Errorf("Syntax error")
+	}
+
+	switch rule[0] {
+	case '>':
+		u.Passwords[HashPassword(rule[1:])] = struct{}{}
+		u.NoPass = false
+	case '<':
+		hash := HashPassword(rule[1:])
+		if _, ok := u.Passwords[hash]; !ok {
+			return fmt.Errorf("The password you are trying to remove from the user does not exist")
+		}
+		delete(u.Passwords, hash)
+	case '#':
+		if !validHash(rule[1:]) {
+			return fmt.Errorf("The password hash must be exactly 64 characters and contain only lowercase hexadecimal characters")
+		}
+		u.Passwords[rule[1:]] = struct{}{}
+		u.NoPass = false
+	case '!':
+		if _, ok := u.Passwords[rule[1:]]; !ok {
+			return fmt.Errorf("The password you are trying to remove from the user does not exist")
+		}
+		delete(u.Passwords, rule[1:])
+	case '~':
+		u.addKeyPattern(rule[1:], PermAll)
+	case '%':
+		perms, pattern, ok := strings.Cut(rule[1:], "~")
+		if !ok || perms == "" {
+			return fmt.Errorf("Syntax error")
+		}
+		perm := 0
+		for _, c := range strings.ToUpper(perms) {
+			switch c {
+			case 'R':
+				perm |= PermRead
+			case 'W':
+				perm |= PermWrite
+			default:
+				return fmt.Errorf("Syntax error")
+			}
+		}
+		u.addKeyPattern(pattern, perm)
+	case '&':
+		u.Channels = append(u.Channels, rule[1:])
+	case '+', '-':
+		allow := rule[0] == '+'
+		if strings.HasPrefix(rule[1:], "@") {
+			category := strings.ToLower(rule[2:])
+			if category != "all" && !validCategory(category) {
+				return fmt.Errorf("Unknown command or category name in ACL")
+			}
+			u.Commands = append(u.Commands, CommandRule{Allow: allow, Category: category})
+		} else {
+			name := strings.ToUpper(rule[1:])
+			if commandExists != nil && !commandExists(name) {
+				return fmt.Errorf("Unknown command or category name in ACL")
+			}
+			u.Commands = append(u.Commands, CommandRule{Allow: allow, Name: name})
+		}
+	default:
+		return fmt.Errorf("Syntax error")
+	}
+	return nil
+}
+
+func (u *User) addKeyPattern(pattern string, perm int) {
+	for i, key := range u.Keys {
+		if key.Pattern == pattern {
+			u.Keys[i].Perm |= perm
+			return
+		}
+	}
+	u.Keys = append(u.Keys, KeyPattern{Pattern: pattern, Perm: perm})
+}
+
+func validHash(hash string) bool {
+	if len(hash) != 64 {
+		return false
+	}
+	for _, c := range hash {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func validCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the flags of the user, as shown by ACL GETUSER.
+func (u *User) Flags() []string {
+	flags := []string{"off"}
+	if u.Enabled {
+		flags[0] = "on"
+	}
+	if u.NoPass {
+		flags = append(flags, "nopass")
+	}
+	return flags
+}
+
+// Returns the password hashes of the user in a stable order.
+func (u *User) PasswordHashes() []string {
+	hashes := make([]string, 0, len(u.Passwords))
+	for hash := range u.Passwords {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+func (u *User) CommandRules() string {
+	if len(u.Commands) == 0 {
+		return "-@all"
+	}
+	rules := make([]string, len(u.Commands))
+	for i, rule := range u.Commands {
+		rules[i] = rule.String()
+	}
+	return strings.Join(rules, " ")
+}
+
+func (u *User) KeyRules() string {
+	if len(u.Keys) == 0 {
+		return "resetkeys"
+	}
+	rules := make([]string, len(u.Keys))
+	for i, key := range u.Keys {
+		switch key.Perm {
+		case PermRead:
+			rules[i] = "%R~" + key.Pattern
+		case PermWrite:
+			rules[i] = "%W~" + key.Pattern
+		default:
+			rules[i] = "~" + key.Pattern
+		}
+	}
+	return strings.Join(rules, " ")
+}
+
+func (u *User) ChannelRules() string {
+	if len(u.Channels) == 0 {
+		return "resetchannels"
+	}
+	rules := make([]string, len(u.Channels))
+	for i, channel := range u.Channels {
+		rules[i] = "&" + channel
+	}
+	return strings.Join(rules, " ")
+}
+
+// Describe the user as a list of rules, in the format used by
+// ACL LIST and the ACL file.
+func (u *User) String() string {
+	rules := []string{"user", u.Name}
+	rules = append(rules, u.Flags()...)
+	for _, hash := range u.PasswordHashes() {
+		rules = append(rules, "#"+hash)
+	}
+	rules = append(rules, u.KeyRules(), u.ChannelRules(), u.CommandRules())
+	return strings.Join(rules, " ")
+}
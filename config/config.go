@@ -0,0 +1,265 @@
+// Configuration of the server. Parameters are read from a config
+// file with one "name value" pair per line, can be overridden from
+// the command line and inspected or changed at runtime through the
+// CONFIG command.
+
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Devansh3712/tandb/glob"
+	"github.com/Devansh3712/tandb/resp"
+)
+
+var (
+	ErrNoConfigFile = errors.New("The server is running without a config file")
+)
+
+type Config struct {
+	Mutex *sync.RWMutex
+	// Path of the config file, used by Rewrite
+	Path   string
+	values map[string]string
+	hooks  map[string][]func(string) error
+}
+
+// Create a config holding the default value of every parameter.
+func New() *Config {
+	config := &Config{
+		Mutex:  &sync.RWMutex{},
+		values: make(map[string]string),
+		hooks:  make(map[string][]func(string) error),
+	}
+	for _, param := range Params {
+		config.values[param.Name] = param.Default
+	}
+	return config
+}
+
+// Read a config file. Lines starting with '#' are comments, and
+// values may be quoted like inline commands.
+func Load(path string) (*Config, error) {
+	config := New()
+	config.Path = path
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		name, value, ok, err := parseLine(scanner.Text())
+		if err == nil && ok {
+			err = config.Override(name, value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+	}
+	return config, scanner.Err()
+}
+
+// Parse a line of the config file. Returns false for empty
+// lines and comments.
+func parseLine(line string) (string, string, bool, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return "", "", false, nil
+	}
+	fields, err := resp.SplitArgs(line)
+	if err != nil {
+		return "", "", false, err
+	}
+	return strings.ToLower(fields[0]), strings.Join(fields[1:], " "), true, nil
+}
+
+// Set a parameter regardless of whether it is mutable, used for the
+// config file and command line arguments before the server starts.
+func (c *Config) Override(name, value string) error {
+	param, ok := lookupParam(name)
+	if !ok {
+		return fmt.Errorf("unknown config parameter '%s'", name)
+	}
+	value, err := param.Validate(value)
+	if err != nil {
+		return fmt.Errorf("invalid value for '%s': %w", param.Name, err)
+	}
+
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	c.values[param.Name] = value
+	return nil
+}
+
+func (c *Config) Get(name string) string {
+	c.Mutex.RLock()
+	defer c.Mutex.RUnlock()
+
+	return c.values[strings.ToLower(name)]
+}
+
+// Returns the value of an integer parameter. Values are validated
+// when they are set, so parsing cannot fail.
+func (c *Config) Int(name string) int {
+	n, _ := strconv.Atoi(c.Get(name))
+	return n
+}
+
+// Returns the parameters whose names match the glob pattern,
+// ignoring case.
+func (c *Config) Match(pattern string) map[string]string {
+	c.Mutex.RLock()
+	defer c.Mutex.RUnlock()
+
+	matched := make(map[string]string)
+	for name, value := range c.values {
+		if glob.MatchFold(pattern, name) {
+			matched[name] = value
+		}
+	}
+	return matched
+}
+
+// Register a function called after a parameter is changed with Set.
+// If the function returns an error, the change is rolled back.
+func (c *Config) OnChange(name string, fn func(string) error) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	c.hooks[name] = append(c.hooks[name], fn)
+}
+
+// A runtime change of a parameter, kept to roll it back.
+type change struct {
+	param    *Param
+	value    string
+	previous string
+}
+
+// Change parameters at runtime, given as name and value pairs. Either
+// every parameter is changed or none is: all values are validated
+// first, and if applying any of them fails the previous values are
+// restored.
+func (c *Config) Set(pairs [][2]string) error {
+	var changes []change
+	for _, pair := range pairs {
+		param, ok := lookupParam(pair[0])
+		if !ok {
+			return fmt.Errorf("Unknown option or number of arguments for CONFIG SET - '%s'", pair[0])
+		}
+		if !param.Mutable {
+			return fmt.Errorf("CONFIG SET failed (possibly related to argument '%s') - can't set immutable config", param.Name)
+		}
+		value, err := param.Validate(pair[1])
+		if err != nil {
+			return fmt.Errorf("CONFIG SET failed (possibly related to argument '%s') - %w", param.Name, err)
+		}
+		changes = append(changes, change{param: param, value: value, previous: c.Get(param.Name)})
+	}
+
+	for i, change := range changes {
+		c.Mutex.Lock()
+		c.values[change.param.Name] = change.value
+		hooks := c.hooks[change.param.Name]
+		c.Mutex.Unlock()
+
+		for _, hook := range hooks {
+			if err := hook(change.value); err != nil {
+				c.rollback(changes[:i+1])
+				return fmt.Errorf("CONFIG SET failed (possibly related to argument '%s') - %w", change.param.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Restore the previous values of applied changes, in reverse order.
+func (c *Config) rollback(changes []change) {
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		c.Mutex.Lock()
+		c.values[change.param.Name] = change.previous
+		hooks := c.hooks[change.param.Name]
+		c.Mutex.Unlock()
+
+		for _, hook := range hooks {
+			hook(change.previous)
+		}
+	}
+}
+
+// Write the current configuration to the config file. Lines of
+// parameters are updated in place, so comments and the order of the
+// file are kept. Parameters which differ from their default but are
+// missing from the file are appended to it.
+func (c *Config) Rewrite() error {
+	if c.Path == "" {
+		return ErrNoConfigFile
+	}
+	content, err := os.ReadFile(c.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	c.Mutex.RLock()
+	defer c.Mutex.RUnlock()
+
+	var lines []string
+	written := make(map[string]bool)
+	if len(content) > 0 {
+		lines = strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	}
+	for i := 0; i < len(lines); i++ {
+		name, _, ok, err := parseLine(lines[i])
+		if err != nil || !ok {
+			continue
+		}
+		if _, known := lookupParam(name); !known {
+			continue
+		}
+		// Drop repeated lines of the same parameter
+		if written[name] {
+			lines = append(lines[:i], lines[i+1:]...)
+			i--
+			continue
+		}
+		lines[i] = formatLine(name, c.values[name])
+		written[name] = true
+	}
+
+	var missing []string
+	for _, param := range Params {
+		if !written[param.Name] && c.values[param.Name] != param.Default {
+			missing = append(missing, formatLine(param.Name, c.values[param.Name]))
+		}
+	}
+	if len(missing) > 0 {
+		lines = append(lines, "", "# Generated by CONFIG REWRITE")
+		lines = append(lines, missing...)
+	}
+
+	temp := c.Path + ".tmp"
+	if err := os.WriteFile(temp, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, c.Path)
+}
+
+// Format a parameter as a line of the config file, quoting
+// values which are empty or contain spaces or quotes.
+func formatLine(name, value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\"'\\") {
+		value = strconv.Quote(value)
+	}
+	return name + " " + value
+}
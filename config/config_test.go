@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tandb.conf")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeTestConfig(t, "# Network\nbind 127.0.0.1 ::1\nPORT 7000\nlogfile \"tan db.log\"\n")
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"bind", "127.0.0.1 ::1"},
+		{"port", "7000"},
+		{"logfile", "tan db.log"},
+		{"maxclients", "10000"},
+	}
+	for _, test := range tests {
+		if got := config.Get(test.name); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.name, got, test.want)
+		}
+	}
+
+	if _, err := Load(writeTestConfig(t, "port 70000\n")); err == nil {
+		t.Errorf("got nil, wanted error")
+	}
+	if _, err := Load(writeTestConfig(t, "unknown yes\n")); err == nil {
+		t.Errorf("got nil, wanted error")
+	}
+}
+
+func TestSet(t *testing.T) {
+	config := New()
+	var applied []string
+	config.OnChange("loglevel", func(value string) error {
+		applied = append(applied, value)
+		return nil
+	})
+	config.OnChange("maxclients", func(value string) error {
+		if value == "5" {
+			return errors.New("too few clients")
+		}
+		return nil
+	})
+
+	if err := config.Set([][2]string{{"port", "7000"}}); err == nil {
+		t.Errorf("got nil, wanted error")
+	}
+	if err := config.Set([][2]string{{"loglevel", "WARNING"}, {"maxclients", "abc"}}); err == nil {
+		t.Errorf("got nil, wanted error")
+	}
+	if got := config.Get("loglevel"); got != "notice" {
+		t.Errorf("got %q, wanted %q", got, "notice")
+	}
+
+	// A failing hook rolls back every change
+	if err := config.Set([][2]string{{"loglevel", "warning"}, {"maxclients", "5"}}); err == nil {
+		t.Errorf("got nil, wanted error")
+	}
+	if got := config.Get("loglevel"); got != "notice" {
+		t.Errorf("got %q, wanted %q", got, "notice")
+	}
+	if len(applied) != 2 || applied[1] != "notice" {
+		t.Errorf("got %v, wanted [warning notice]", applied)
+	}
+
+	if err := config.Set([][2]string{{"maxclients", "100"}}); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	if got := config.Int("maxclients"); got != 100 {
+		t.Errorf("got %d, wanted %d", got, 100)
+	}
+	if got := len(config.Match("tls-*")); got != 8 {
+		t.Errorf("got %d, wanted %d", got, 8)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	path := writeTestConfig(t, "# Limits\nmaxclients 10\nmaxclients 20\n")
+	config, err := Load(path)
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	if err := config.Set([][2]string{{"maxclients", "30"}, {"requirepass", "two words"}}); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	if err := config.Rewrite(); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+
+	content, _ := os.ReadFile(path)
+	want := "# Limits\nmaxclients 30\n\n# Generated by CONFIG REWRITE\nrequirepass \"two words\"\n"
+	if string(content) != want {
+		t.Errorf("got %q, wanted %q", content, want)
+	}
+
+	if err := New().Rewrite(); err != ErrNoConfigFile {
+		t.Errorf("got %v, wanted %v", err, ErrNoConfigFile)
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Param describes a configuration parameter. Validate checks a value
+// and returns it in its canonical form. Immutable parameters can only
+// be set from the config file or the command line.
+type Param struct {
+	Name     string
+	Default  string
+	Mutable  bool
+	Validate func(string) (string, error)
+}
+
+// Parameters supported by tanDB, in the order used when new lines
+// are appended to the config file.
+var Params = []*Param{
+	// Network
+	{Name: "bind", Default: "", Validate: anyString},
+	{Name: "port", Default: "8000", Validate: intRange(0, 65535)},
+	{Name: "unixsocket", Default: "", Validate: anyString},
+	{Name: "unixsocketperm", Default: "700", Validate: octal},
+	{Name: "maxclients", Default: "10000", Mutable: true, Validate: intRange(1, 1<<20)},
+	{Name: "proto-max-bulk-len", Default: "536870912", Mutable: true, Validate: intRange(1024, 512*1024*1024)},
+	{Name: "shutdown-timeout", Default: "10", Mutable: true, Validate: intRange(0, 3600)},
+	// TLS
+	{Name: "tls-port", Default: "0", Validate: intRange(0, 65535)},
+	{Name: "tls-cert-file", Default: "", Validate: anyString},
+	{Name: "tls-key-file", Default: "", Validate: anyString},
+	{Name: "tls-ca-cert-file", Default: "", Validate: anyString},
+	{Name: "tls-auth-clients", Default: "no", Validate: oneOf("no", "optional", "yes")},
+	{Name: "tls-auth-clients-user", Default: "off", Validate: oneOf("off", "CN")},
+	{Name: "tls-ciphers", Default: "", Validate: anyString},
+	{Name: "tls-min-version", Default: "TLSv1.2", Validate: oneOf("TLSv1.2", "TLSv1.3")},
+	// Metrics
+	{Name: "metrics-bind", Default: "", Validate: anyString},
+	{Name: "metrics-port", Default: "0", Validate: intRange(0, 65535)},
+	// Security
+	{Name: "aclfile", Default: "", Validate: anyString},
+	{Name: "requirepass", Default: "", Mutable: true, Validate: anyString},
+	// Keyspace
+	// Interval of the expired keys sweeper in milliseconds, and the
+	// share of each interval it may run for, in percent
+	{Name: "ttl-sweep-interval", Default: "100", Mutable: true, Validate: intRange(1, 60000)},
+	{Name: "ttl-sweep-budget", Default: "25", Mutable: true, Validate: intRange(1, 100)},
+	// Slow log, with the threshold in microseconds. A negative
+	// threshold disables the slow log and 0 logs every command.
+	{Name: "slowlog-log-slower-than", Default: "10000", Mutable: true, Validate: intRange(-1, 1<<31-1)},
+	{Name: "slowlog-max-len", Default: "128", Mutable: true, Validate: intRange(0, 1<<20)},
+	// Logging
+	{Name: "loglevel", Default: "notice", Mutable: true, Validate: oneOf("debug", "verbose", "notice", "warning")},
+	{Name: "logfile", Default: "", Validate: anyString},
+	// Persistence
+	{Name: "dir", Default: ".", Validate: anyString},
+	{Name: "dbfilename", Default: "dump.tdb", Mutable: true, Validate: fileName},
+}
+
+func lookupParam(name string) (*Param, bool) {
+	name = strings.ToLower(name)
+	for _, param := range Params {
+		if param.Name == name {
+			return param, true
+		}
+	}
+	return nil, false
+}
+
+func anyString(value string) (string, error) {
+	return value, nil
+}
+
+func intRange(min, max int) func(string) (string, error) {
+	return func(value string) (string, error) {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return "", fmt.Errorf("argument couldn't be parsed into an integer")
+		}
+		if n < min || n > max {
+			return "", fmt.Errorf("argument must be between %d and %d inclusive", min, max)
+		}
+		return strconv.Itoa(n), nil
+	}
+}
+
+func oneOf(values ...string) func(string) (string, error) {
+	return func(value string) (string, error) {
+		for _, v := range values {
+			if strings.EqualFold(v, value) {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("argument(s) must be one of the following: %s", strings.Join(values, ", "))
+	}
+}
+
+func octal(value string) (string, error) {
+	n, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || n > 0777 {
+		return "", fmt.Errorf("argument must be octal permissions, such as 700")
+	}
+	return strconv.FormatUint(n, 8), nil
+}
+
+func fileName(value string) (string, error) {
+	if value == "" || strings.ContainsRune(value, '/') {
+		return "", fmt.Errorf("dbfilename can't be a path, just a filename")
+	}
+	return value, nil
+}
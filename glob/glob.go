@@ -0,0 +1,124 @@
+// Glob style pattern matching, following the rules used by Redis for
+// KEYS, ACL key patterns and CONFIG GET:
+//
+//   - '*' matches any sequence of bytes, including an empty one.
+//   - '?' matches a single byte.
+//   - '[abc]' matches one of the bytes in the brackets, '[^abc]' any
+//     byte not in the brackets and '[a-z]' a range of bytes.
+//   - '\' escapes the next byte, so it is matched literally.
+
+package glob
+
+// Check if the string matches the pattern.
+func Match(pattern, s string) bool {
+	return match(pattern, s, false)
+}
+
+// Check if the string matches the pattern, ignoring the case of
+// ASCII letters.
+func MatchFold(pattern, s string) bool {
+	return match(pattern, s, true)
+}
+
+func lower(c byte, fold bool) byte {
+	if fold && c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// Match with two indices, backtracking only to the last star: the
+// star then absorbs one more byte of the string and matching resumes
+// after it. Earlier stars never need to be revisited, so matching
+// takes at most len(pattern) * len(s) steps.
+func match(pattern, s string, fold bool) bool {
+	p, i := 0, 0
+	// Index of the pattern after the last star, and of the string
+	// where matching resumes after it, if there was a star
+	star, next := -1, 0
+	for i < len(s) {
+		if p < len(pattern) {
+			if pattern[p] == '*' {
+				for p < len(pattern) && pattern[p] == '*' {
+					p++
+				}
+				star, next = p, i
+				continue
+			}
+			if n, ok := matchByte(pattern[p:], s[i], fold); ok {
+				p += n
+				i++
+				continue
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		next++
+		p, i = star, next
+	}
+	// The rest of the pattern can only match the empty string
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// Match a byte against the first element of a pattern which is not a
+// star. Returns the length of the element and whether the byte matched.
+func matchByte(pattern string, c byte, fold bool) (int, bool) {
+	switch pattern[0] {
+	case '?':
+		return 1, true
+	case '[':
+		matched, rest := matchClass(pattern[1:], c, fold)
+		return len(pattern) - len(rest), matched
+	case '\\':
+		if len(pattern) >= 2 {
+			return 2, lower(pattern[1], fold) == lower(c, fold)
+		}
+	}
+	return 1, lower(pattern[0], fold) == lower(c, fold)
+}
+
+// Match a byte against a character class, with the pattern starting
+// after the opening bracket. Returns whether the byte matched and the
+// rest of the pattern after the closing bracket.
+func matchClass(pattern string, c byte, fold bool) (bool, string) {
+	negate := false
+	if len(pattern) > 0 && pattern[0] == '^' {
+		negate = true
+		pattern = pattern[1:]
+	}
+	c = lower(c, fold)
+
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) >= 2:
+			if lower(pattern[1], fold) == c {
+				matched = true
+			}
+			pattern = pattern[2:]
+		case len(pattern) >= 3 && pattern[1] == '-' && pattern[2] != ']':
+			start, end := lower(pattern[0], fold), lower(pattern[2], fold)
+			if start > end {
+				start, end = end, start
+			}
+			if c >= start && c <= end {
+				matched = true
+			}
+			pattern = pattern[3:]
+		default:
+			if lower(pattern[0], fold) == c {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+	// Skip the closing bracket, an unterminated class ends the pattern
+	if len(pattern) > 0 {
+		pattern = pattern[1:]
+	}
+	return matched != negate, pattern
+}
@@ -0,0 +1,69 @@
+package glob
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"user:*", "user:1000", true},
+		{"user:*", "session:1", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"h[a-b]llo", "hcllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+		{"**a", "ba", true},
+		{"a*", "a", true},
+		{"*a?", "aab", true},
+		{"*[xy]z", "xxyz", true},
+		{"a[", "a", false},
+		{"h*", "", false},
+		{`*\`, `a\`, true},
+	}
+
+	for _, test := range tests {
+		got := Match(test.pattern, test.s)
+		if got != test.want {
+			t.Errorf("Match(%q, %q): got %t, wanted %t", test.pattern, test.s, got, test.want)
+		}
+	}
+}
+
+func TestMatchFold(t *testing.T) {
+	got := MatchFold("max*", "MaxClients")
+	want := true
+	if got != want {
+		t.Errorf("got %t, wanted %t", got, want)
+	}
+}
+
+func TestMatchBacktracking(t *testing.T) {
+	// Every star could absorb any part of the key, which takes an
+	// exponential time when each choice is retried
+	pattern := strings.Repeat("*a", 30) + "*b"
+	s := strings.Repeat("a", 60)
+
+	start := time.Now()
+	if Match(pattern, s) {
+		t.Errorf("Match(%q, %q): got true, wanted false", pattern, s)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("got %s, wanted under 100ms", elapsed)
+	}
+}
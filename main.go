@@ -1,8 +1,73 @@
 package main
 
-import "github.com/Devansh3712/tandb/server"
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Devansh3712/tandb/config"
+	"github.com/Devansh3712/tandb/server"
+)
 
 func main() {
-	server := server.NewServer(":8000")
-	server.Start()
+	configFile := flag.String("config", "", "path of the config file")
+	// Every config parameter can be overridden from the command line,
+	// and overrides are applied in the order they were given
+	var overrides [][2]string
+	for _, param := range config.Params {
+		name := param.Name
+		usage := fmt.Sprintf("override the %s config parameter (default %q)", name, param.Default)
+		flag.Func(name, usage, func(value string) error {
+			overrides = append(overrides, [2]string{name, value})
+			return nil
+		})
+	}
+	flag.Parse()
+
+	cfg := config.New()
+	if *configFile != "" {
+		var err error
+		if cfg, err = config.Load(*configFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, override := range overrides {
+		if err := cfg.Override(override[0], override[1]); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if path := cfg.Get("logfile"); path != "" {
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		log.SetOutput(file)
+	}
+
+	srv, err := server.NewServerFromConfig(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Printf("received %s, shutting down", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), srv.ShutdownTimeout())
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("unable to shut down gracefully: %v", err)
+		}
+	}()
+
+	if err := srv.Start(); err != server.ErrServerClosed {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,131 @@
+package resp
+
+import "errors"
+
+var ErrUnbalancedQuotes = errors.New("Protocol error: unbalanced quotes in request")
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\n', '\r', '\t', '\v', '\f':
+		return true
+	}
+	return false
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func hexDigitToInt(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
+
+// Split an inline command into arguments, following the quoting
+// rules of redis-cli. Arguments are separated by whitespace and may
+// be quoted:
+//
+//   - Double quoted strings support the escapes \n, \r, \t, \b, \a
+//     and \xHH for an arbitrary byte. Any other escaped character
+//     stands for itself.
+//   - Single quoted strings are literal, except for \' which stands
+//     for a single quote.
+//
+// A closing quote must be followed by whitespace or the end of the
+// line. The arguments may contain arbitrary bytes.
+func SplitArgs(line string) ([]string, error) {
+	args := []string{}
+	i := 0
+	for {
+		// Skip whitespace between arguments
+		for i < len(line) && isSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		var arg []byte
+		inDouble, inSingle, done := false, false, false
+		for !done {
+			if inDouble {
+				if i == len(line) {
+					return nil, ErrUnbalancedQuotes
+				}
+				c := line[i]
+				switch {
+				case c == '\\' && i+3 < len(line) && line[i+1] == 'x' &&
+					isHexDigit(line[i+2]) && isHexDigit(line[i+3]):
+					arg = append(arg, hexDigitToInt(line[i+2])<<4|hexDigitToInt(line[i+3]))
+					i += 3
+				case c == '\\' && i+1 < len(line):
+					i++
+					switch line[i] {
+					case 'n':
+						c = '\n'
+					case 'r':
+						c = '\r'
+					case 't':
+						c = '\t'
+					case 'b':
+						c = '\b'
+					case 'a':
+						c = '\a'
+					default:
+						c = line[i]
+					}
+					arg = append(arg, c)
+				case c == '"':
+					// The closing quote must be followed by a space
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg = append(arg, c)
+				}
+			} else if inSingle {
+				if i == len(line) {
+					return nil, ErrUnbalancedQuotes
+				}
+				c := line[i]
+				switch {
+				case c == '\\' && i+1 < len(line) && line[i+1] == '\'':
+					arg = append(arg, '\'')
+					i++
+				case c == '\'':
+					if i+1 < len(line) && !isSpace(line[i+1]) {
+						return nil, ErrUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg = append(arg, c)
+				}
+			} else {
+				if i == len(line) {
+					break
+				}
+				switch c := line[i]; {
+				case isSpace(c):
+					done = true
+				case c == '"':
+					inDouble = true
+				case c == '\'':
+					inSingle = true
+				default:
+					arg = append(arg, c)
+				}
+			}
+			if i < len(line) {
+				i++
+			}
+		}
+		args = append(args, string(arg))
+	}
+}
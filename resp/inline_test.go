@@ -0,0 +1,39 @@
+package resp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"SET  key   value\r\n", []string{"SET", "key", "value"}},
+		{`SET key "hello world"`, []string{"SET", "key", "hello world"}},
+		{`SET key "a\x00\xffb\n\"c"`, []string{"SET", "key", "a\x00\xffb\n\"c"}},
+		{`SET key 'it\'s \x41'`, []string{"SET", "key", `it's \x41`}},
+		{`SET key ""`, []string{"SET", "key", ""}},
+		{"   ", []string{}},
+	}
+
+	for _, test := range tests {
+		got, err := SplitArgs(test.line)
+		if err != nil {
+			t.Errorf("got %s, wanted nil", err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("got %q, wanted %q", got, test.want)
+		}
+	}
+}
+
+func TestSplitArgsUnbalanced(t *testing.T) {
+	for _, line := range []string{`SET key "value`, `SET key 'value`, `SET key "a"b`} {
+		if _, err := SplitArgs(line); err != ErrUnbalancedQuotes {
+			t.Errorf("got %v, wanted %v", err, ErrUnbalancedQuotes)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package resp
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type Reader struct {
+	rd *bufio.Reader
+	// Largest bulk string accepted from the connection
+	MaxBulkLen int
+}
+
+func NewReader(rd io.Reader) *Reader {
+	return &Reader{rd: bufio.NewReader(rd), MaxBulkLen: MaxBulkLen}
+}
+
+// Number of bytes which have been read from the connection but
+// not consumed yet. A non zero value means the client has pipelined
+// more commands.
+func (r *Reader) Buffered() int {
+	return r.rd.Buffered()
+}
+
+// Read the next command from the connection. A command is either a RESP
+// array of bulk strings or an inline command terminated by a newline,
+// split into arguments by SplitArgs. Empty arrays and empty inline
+// lines are skipped.
+func (r *Reader) ReadCommand() ([]string, error) {
+	for {
+		prefix, err := r.rd.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		var args []string
+		if prefix[0] == Array {
+			args, err = r.readArray()
+		} else {
+			args, err = r.readInline()
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(args) > 0 {
+			return args, nil
+		}
+	}
+}
+
+// Read a line up to its newline, failing with ErrInlineTooBig once it
+// is longer than MaxInlineLen, so that a client which never sends a
+// newline cannot make the line grow without bound.
+func (r *Reader) readBoundedLine() (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.rd.ReadSlice('\n')
+		if len(line)+len(chunk) > MaxInlineLen {
+			return "", ErrInlineTooBig
+		}
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return string(line), nil
+	}
+}
+
+// Read a line terminated by CRLF and return it without the terminator.
+func (r *Reader) readLine() (string, error) {
+	line, err := r.readBoundedLine()
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", ErrInvalidLine
+	}
+	return line[:len(line)-2], nil
+}
+
+func (r *Reader) readArray() ([]string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(line[1:])
+	if err != nil || count < 0 || count > MaxArrayLen {
+		return nil, ErrInvalidArrayLen
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		arg, err := r.readBulk()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func (r *Reader) readBulk() (string, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return "", err
+	}
+	if len(line) == 0 || line[0] != BulkString {
+		return "", ErrExpectedBulk
+	}
+	size, err := strconv.Atoi(line[1:])
+	if err != nil || size < 0 || size > r.MaxBulkLen {
+		return "", ErrInvalidBulkLen
+	}
+
+	// The data is buffered as it arrives instead of being allocated
+	// from the announced size, so that a header alone cannot make the
+	// server allocate up to MaxBulkLen
+	var data strings.Builder
+	data.Grow(min(size, bulkPreallocLen))
+	if _, err := io.CopyN(&data, r.rd, int64(size)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	var crlf [2]byte
+	if _, err := io.ReadFull(r.rd, crlf[:]); err != nil {
+		return "", err
+	}
+	if crlf != [2]byte{'\r', '\n'} {
+		return "", ErrInvalidLine
+	}
+	return data.String(), nil
+}
+
+// Largest bulk string buffer allocated before its data has arrived.
+const bulkPreallocLen = 64 * 1024
+
+func (r *Reader) readInline() ([]string, error) {
+	line, err := r.readBoundedLine()
+	if err != nil {
+		return nil, err
+	}
+	return SplitArgs(line)
+}
@@ -0,0 +1,50 @@
+// Package resp implements the REdis Serialization Protocol (RESP), the
+// wire protocol used by Redis clients. Commands are read either as an
+// array of bulk strings or as a single inline line, and replies are
+// encoded as typed RESP values.
+
+package resp
+
+import "errors"
+
+const (
+	SimpleString = '+'
+	Error        = '-'
+	Integer      = ':'
+	BulkString   = '$'
+	Array        = '*'
+	// RESP3 types
+	Null           = '_'
+	Double         = ','
+	Boolean        = '#'
+	BigNumber      = '('
+	BulkError      = '!'
+	VerbatimString = '='
+	Map            = '%'
+	Set            = '~'
+	Attribute      = '|'
+	Push           = '>'
+)
+
+// Protocol versions which can be negotiated with HELLO.
+const (
+	RESP2 = 2
+	RESP3 = 3
+)
+
+const (
+	// Maximum length of a single bulk string (512 MB).
+	MaxBulkLen = 512 * 1024 * 1024
+	// Maximum number of elements in a multi bulk request.
+	MaxArrayLen = 1024 * 1024
+	// Maximum length of an inline request.
+	MaxInlineLen = 64 * 1024
+)
+
+var (
+	ErrInvalidArrayLen = errors.New("Protocol error: invalid multibulk length")
+	ErrInvalidBulkLen  = errors.New("Protocol error: invalid bulk length")
+	ErrExpectedBulk    = errors.New("Protocol error: expected '$'")
+	ErrInlineTooBig    = errors.New("Protocol error: too big inline request")
+	ErrInvalidLine     = errors.New("Protocol error: line is not terminated by CRLF")
+)
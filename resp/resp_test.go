@@ -0,0 +1,166 @@
+package resp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadArray(t *testing.T) {
+	reader := NewReader(strings.NewReader("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nhe\r\nl\r\n"))
+
+	got, err := reader.ReadCommand()
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	want := []string{"SET", "key", "he\r\nl"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestReadInline(t *testing.T) {
+	reader := NewReader(strings.NewReader("\r\nGET key\r\n"))
+
+	got, err := reader.ReadCommand()
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	want := []string{"GET", "key"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestReadInvalidBulk(t *testing.T) {
+	reader := NewReader(strings.NewReader("*1\r\n$-5\r\n"))
+
+	if _, err := reader.ReadCommand(); err != ErrInvalidBulkLen {
+		t.Errorf("got %v, wanted %v", err, ErrInvalidBulkLen)
+	}
+}
+
+func TestReadEmptyArray(t *testing.T) {
+	reader := NewReader(strings.NewReader("*0\r\n*1\r\n$4\r\nPING\r\n"))
+
+	got, err := reader.ReadCommand()
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	want := []string{"PING"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestReadNegativeArray(t *testing.T) {
+	reader := NewReader(strings.NewReader("*-1\r\n"))
+
+	if _, err := reader.ReadCommand(); err != ErrInvalidArrayLen {
+		t.Errorf("got %v, wanted %v", err, ErrInvalidArrayLen)
+	}
+}
+
+func TestReadInlineTooBig(t *testing.T) {
+	// A line with no newline is rejected once it passes the limit,
+	// without reading the rest of the stream
+	stream := io.MultiReader(strings.NewReader(strings.Repeat("a", MaxInlineLen+1)), neverEnding{})
+	reader := NewReader(stream)
+
+	if _, err := reader.ReadCommand(); err != ErrInlineTooBig {
+		t.Errorf("got %v, wanted %v", err, ErrInlineTooBig)
+	}
+}
+
+// A reader which never runs out of bytes and never sends a newline.
+type neverEnding struct{}
+
+func (neverEnding) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'a'
+	}
+	return len(p), nil
+}
+
+func TestReadTruncatedBulk(t *testing.T) {
+	// The announced size is not allocated up front, and a stream which
+	// ends early fails
+	reader := NewReader(strings.NewReader("*1\r\n$536870912\r\nshort"))
+
+	if _, err := reader.ReadCommand(); err != io.ErrUnexpectedEOF {
+		t.Errorf("got %v, wanted %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestReadLargeBulk(t *testing.T) {
+	data := strings.Repeat("x", 3*bulkPreallocLen+5)
+	reader := NewReader(strings.NewReader(fmt.Sprintf("*1\r\n$%d\r\n%s\r\n", len(data), data)))
+
+	got, err := reader.ReadCommand()
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	if len(got) != 1 || got[0] != data {
+		t.Errorf("got %d bytes, wanted %d", len(got[0]), len(data))
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewWriter(&buf)
+
+	writer.WriteArray(4)
+	writer.WriteSimpleString("OK")
+	writer.WriteInteger(-12)
+	writer.WriteBulkString("value")
+	writer.WriteNull()
+	writer.WriteError("ERR failed")
+	writer.Flush()
+
+	got := buf.String()
+	want := "*4\r\n+OK\r\n:-12\r\n$5\r\nvalue\r\n$-1\r\n-ERR failed\r\n"
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestWriterRESP3(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewWriter(&buf)
+	writer.Protocol = RESP3
+
+	writer.WriteMap(1)
+	writer.WriteBulkString("a")
+	writer.WriteSet(2)
+	writer.WriteBool(true)
+	writer.WriteDouble(1.5)
+	writer.WriteNull()
+	writer.Flush()
+
+	got := buf.String()
+	want := "%1\r\n$1\r\na\r\n~2\r\n#t\r\n,1.5\r\n_\r\n"
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestWriterDowngrade(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewWriter(&buf)
+
+	writer.WriteMap(1)
+	writer.WriteBool(false)
+	writer.WriteDouble(2)
+	writer.WriteAttribute(map[string]string{"key": "value"})
+	writer.WriteNull()
+	writer.Flush()
+
+	got := buf.String()
+	want := "*2\r\n:0\r\n$1\r\n2\r\n$-1\r\n"
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package resp
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Size of the reply buffer. Replies are flushed to the underlying
+// writer once the buffer is full, or explicitly through Flush.
+const WriteBufferSize = 16 * 1024
+
+// Writer encodes replies for a single connection. Types which
+// do not exist in RESP2 are downgraded to their closest RESP2
+// equivalent unless the protocol has been switched to RESP3.
+//
+// Replies are buffered, and must be flushed with Flush.
+type Writer struct {
+	wr       *bufio.Writer
+	Protocol int
+}
+
+func NewWriter(wr io.Writer) *Writer {
+	return &Writer{wr: bufio.NewWriterSize(wr, WriteBufferSize), Protocol: RESP2}
+}
+
+// Write any buffered replies to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.wr.Flush()
+}
+
+// Number of bytes of replies waiting to be flushed.
+func (w *Writer) Buffered() int {
+	return w.wr.Buffered()
+}
+
+func (w *Writer) writeLine(prefix byte, line string) error {
+	buf := make([]byte, 0, len(line)+3)
+	buf = append(buf, prefix)
+	buf = append(buf, line...)
+	buf = append(buf, '\r', '\n')
+	_, err := w.wr.Write(buf)
+	return err
+}
+
+// Write a length prefixed payload, used by bulk and verbatim strings.
+func (w *Writer) writeBlob(prefix byte, b []byte) error {
+	buf := make([]byte, 0, len(b)+16)
+	buf = append(buf, prefix)
+	buf = strconv.AppendInt(buf, int64(len(b)), 10)
+	buf = append(buf, '\r', '\n')
+	buf = append(buf, b...)
+	buf = append(buf, '\r', '\n')
+	_, err := w.wr.Write(buf)
+	return err
+}
+
+// Write a simple string, such as "OK". The string must not
+// contain CR or LF characters.
+func (w *Writer) WriteSimpleString(s string) error {
+	return w.writeLine(SimpleString, s)
+}
+
+// Write an error reply. By convention the first word of the
+// message is the error code, such as "ERR" or "WRONGTYPE".
+func (w *Writer) WriteError(msg string) error {
+	return w.writeLine(Error, msg)
+}
+
+func (w *Writer) WriteInteger(n int64) error {
+	return w.writeLine(Integer, strconv.FormatInt(n, 10))
+}
+
+// Write a binary safe bulk string.
+func (w *Writer) WriteBulk(b []byte) error {
+	return w.writeBlob(BulkString, b)
+}
+
+func (w *Writer) WriteBulkString(s string) error {
+	return w.WriteBulk([]byte(s))
+}
+
+// Write a null value, used for missing values. RESP2 uses a
+// null bulk string.
+func (w *Writer) WriteNull() error {
+	if w.Protocol == RESP3 {
+		return w.writeLine(Null, "")
+	}
+	return w.writeLine(BulkString, "-1")
+}
+
+// Write the header of an array with n elements. The caller must
+// write exactly n values after it.
+func (w *Writer) WriteArray(n int) error {
+	return w.writeLine(Array, strconv.Itoa(n))
+}
+
+// Write the header of a map with n key-value pairs. The caller
+// must write 2n values after it. RESP2 uses a flat array.
+func (w *Writer) WriteMap(n int) error {
+	if w.Protocol == RESP3 {
+		return w.writeLine(Map, strconv.Itoa(n))
+	}
+	return w.WriteArray(2 * n)
+}
+
+// Write the header of an unordered set with n elements.
+// RESP2 uses an array.
+func (w *Writer) WriteSet(n int) error {
+	if w.Protocol == RESP3 {
+		return w.writeLine(Set, strconv.Itoa(n))
+	}
+	return w.WriteArray(n)
+}
+
+// Write the header of an out of band push message with n
+// elements. RESP2 uses an array.
+func (w *Writer) WritePush(n int) error {
+	if w.Protocol == RESP3 {
+		return w.writeLine(Push, strconv.Itoa(n))
+	}
+	return w.WriteArray(n)
+}
+
+// Write a boolean. RESP2 uses the integers 1 and 0.
+func (w *Writer) WriteBool(b bool) error {
+	if w.Protocol == RESP3 {
+		if b {
+			return w.writeLine(Boolean, "t")
+		}
+		return w.writeLine(Boolean, "f")
+	}
+	if b {
+		return w.WriteInteger(1)
+	}
+	return w.WriteInteger(0)
+}
+
+// Write a floating point number. RESP2 uses a bulk string.
+func (w *Writer) WriteDouble(f float64) error {
+	var s string
+	switch {
+	case math.IsInf(f, 1):
+		s = "inf"
+	case math.IsInf(f, -1):
+		s = "-inf"
+	case math.IsNaN(f):
+		s = "nan"
+	default:
+		s = strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if w.Protocol == RESP3 {
+		return w.writeLine(Double, s)
+	}
+	return w.WriteBulkString(s)
+}
+
+// Write an integer which may not fit in 64 bits, given as a
+// decimal string. RESP2 uses a bulk string.
+func (w *Writer) WriteBigNumber(n string) error {
+	if w.Protocol == RESP3 {
+		return w.writeLine(BigNumber, n)
+	}
+	return w.WriteBulkString(n)
+}
+
+// Write a verbatim string with a three character format such as
+// "txt" or "mkd". RESP2 uses a bulk string.
+func (w *Writer) WriteVerbatim(format, s string) error {
+	if w.Protocol == RESP3 {
+		return w.writeBlob(VerbatimString, []byte(format+":"+s))
+	}
+	return w.WriteBulkString(s)
+}
+
+// Write attributes describing the reply that follows. RESP2 has
+// no way to represent attributes, so nothing is written.
+func (w *Writer) WriteAttribute(attrs map[string]string) error {
+	if w.Protocol != RESP3 {
+		return nil
+	}
+	if err := w.writeLine(Attribute, strconv.Itoa(len(attrs))); err != nil {
+		return err
+	}
+	for key, value := range attrs {
+		if err := w.WriteBulkString(key); err != nil {
+			return err
+		}
+		if err := w.WriteBulkString(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
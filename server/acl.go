@@ -0,0 +1,225 @@
+package server
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Devansh3712/tandb/acl"
+)
+
+// Check if the client may run the command, before it is dispatched
+// to its handler. Commands which can run without authentication are
+// always allowed. The categories of a subcommand with its own spec
+// replace the categories of the command.
+func (s *Server) checkPermission(cmd Command, spec *CommandSpec) error {
+	if spec.HasFlag(FlagNoAuth) {
+		return nil
+	}
+	user, authenticated := cmd.Client.GetUser()
+	if !authenticated {
+		return ErrNoAuth
+	}
+
+	perm := acl.PermAll
+	if spec.HasFlag(FlagReadonly) {
+		perm = acl.PermRead
+	} else if spec.HasFlag(FlagWrite) {
+		perm = acl.PermWrite
+	}
+	return s.ACL.Check(
+		user, spec.Name, spec.subcommand(cmd.Args).ACLCategories(), spec.Keys(cmd.Args), perm, cmd.Client.Info,
+	)
+}
+
+// ACL SETUSER | GETUSER | DELUSER | LIST | WHOAMI | CAT | LOG | SAVE | LOAD
+func (s *Server) acl(cmd Command) {
+	sub := strings.ToUpper(cmd.Args[0])
+	args := cmd.Args[1:]
+
+	switch sub {
+	case "SETUSER":
+		if len(args) < 1 {
+			cmd.error(wrongArity("acl|setuser"))
+			return
+		}
+		if err := s.ACL.SetUser(args[0], args[1:]); err != nil {
+			cmd.error(err)
+			return
+		}
+		cmd.ok()
+	case "GETUSER":
+		if len(args) != 1 {
+			cmd.error(wrongArity("acl|getuser"))
+			return
+		}
+		s.aclGetUser(cmd, args[0])
+	case "DELUSER":
+		if len(args) < 1 {
+			cmd.error(wrongArity("acl|deluser"))
+			return
+		}
+		deleted := 0
+		for _, name := range args {
+			ok, err := s.ACL.DelUser(name)
+			if err != nil {
+				cmd.error(err)
+				return
+			}
+			if ok {
+				deleted++
+				// Disconnect the clients authenticated as the user
+				s.KillClients(cmd.Client, func(c *Client) bool {
+					user, authenticated := c.GetUser()
+					return authenticated && user == name
+				})
+			}
+		}
+		cmd.integer(deleted)
+	case "LIST":
+		if len(args) != 0 {
+			cmd.error(wrongArity("acl|list"))
+			return
+		}
+		cmd.array(s.ACL.List())
+	case "WHOAMI":
+		if len(args) != 0 {
+			cmd.error(wrongArity("acl|whoami"))
+			return
+		}
+		user, _ := cmd.Client.GetUser()
+		cmd.bulk([]byte(user))
+	case "CAT":
+		s.aclCat(cmd, args)
+	case "LOG":
+		s.aclLog(cmd, args)
+	case "SAVE":
+		if err := s.ACL.Save(s.ACLFile); err != nil {
+			cmd.error(err)
+			return
+		}
+		cmd.ok()
+	case "LOAD":
+		if err := s.ACL.Load(s.ACLFile); err != nil {
+			cmd.error(err)
+			return
+		}
+		cmd.ok()
+	default:
+		cmd.error(unknownSubcommand(CMD_ACL, sub))
+	}
+}
+
+func (s *Server) aclGetUser(cmd Command, name string) {
+	user, ok := s.ACL.GetUser(name)
+	if !ok {
+		cmd.null()
+		return
+	}
+	writer := cmd.Client.Writer
+	cmd.logError(writer.WriteMap(6))
+	cmd.logError(writer.WriteBulkString("flags"))
+	cmd.array(user.Flags())
+	cmd.logError(writer.WriteBulkString("passwords"))
+	cmd.array(user.PasswordHashes())
+	cmd.logError(writer.WriteBulkString("commands"))
+	cmd.logError(writer.WriteBulkString(user.CommandRules()))
+	cmd.logError(writer.WriteBulkString("keys"))
+	cmd.logError(writer.WriteBulkString(user.KeyRules()))
+	cmd.logError(writer.WriteBulkString("channels"))
+	cmd.logError(writer.WriteBulkString(user.ChannelRules()))
+	cmd.logError(writer.WriteBulkString("selectors"))
+	cmd.array(nil)
+}
+
+// ACL CAT [category]
+//
+// List the command categories, or the commands in a category.
+func (s *Server) aclCat(cmd Command, args []string) {
+	switch len(args) {
+	case 0:
+		cmd.array(acl.Categories)
+	case 1:
+		category := strings.ToLower(args[0])
+		inCategory := func(spec *CommandSpec) bool {
+			for _, c := range spec.ACLCategories() {
+				if c == category {
+					return true
+				}
+			}
+			return false
+		}
+		var names []string
+		for _, spec := range Commands() {
+			if inCategory(spec) {
+				names = append(names, strings.ToLower(spec.Name))
+			}
+			var subcommands []string
+			for _, sub := range spec.Subcommands {
+				if inCategory(sub) {
+					subcommands = append(subcommands, sub.Name)
+				}
+			}
+			sort.Strings(subcommands)
+			names = append(names, subcommands...)
+		}
+		if names == nil {
+			cmd.error(errors.New("Unknown category '" + args[0] + "'"))
+			return
+		}
+		cmd.array(names)
+	default:
+		cmd.error(wrongArity("acl|cat"))
+	}
+}
+
+// ACL LOG [count | RESET]
+func (s *Server) aclLog(cmd Command, args []string) {
+	count := 10
+	switch {
+	case len(args) == 1 && strings.ToUpper(args[0]) == "RESET":
+		s.ACL.ResetLog()
+		cmd.ok()
+		return
+	case len(args) == 1:
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			cmd.error(errors.New("value is out of range, must be positive"))
+			return
+		}
+		count = n
+	case len(args) > 1:
+		cmd.error(wrongArity("acl|log"))
+		return
+	}
+
+	entries := s.ACL.LogEntries(count)
+	writer := cmd.Client.Writer
+	now := time.Now()
+	cmd.logError(writer.WriteArray(len(entries)))
+	for _, entry := range entries {
+		cmd.logError(writer.WriteMap(10))
+		cmd.logError(writer.WriteBulkString("count"))
+		cmd.integer(entry.Count)
+		cmd.logError(writer.WriteBulkString("reason"))
+		cmd.logError(writer.WriteBulkString(entry.Reason))
+		cmd.logError(writer.WriteBulkString("context"))
+		cmd.logError(writer.WriteBulkString(entry.Context))
+		cmd.logError(writer.WriteBulkString("object"))
+		cmd.logError(writer.WriteBulkString(entry.Object))
+		cmd.logError(writer.WriteBulkString("username"))
+		cmd.logError(writer.WriteBulkString(entry.Username))
+		cmd.logError(writer.WriteBulkString("age-seconds"))
+		cmd.logError(writer.WriteDouble(now.Sub(entry.Created).Seconds()))
+		cmd.logError(writer.WriteBulkString("client-info"))
+		cmd.logError(writer.WriteBulkString(entry.ClientInfo))
+		cmd.logError(writer.WriteBulkString("entry-id"))
+		cmd.integer(int(entry.ID))
+		cmd.logError(writer.WriteBulkString("timestamp-created"))
+		cmd.integer(int(entry.Created.UnixMilli()))
+		cmd.logError(writer.WriteBulkString("timestamp-last-updated"))
+		cmd.integer(int(entry.Updated.UnixMilli()))
+	}
+}
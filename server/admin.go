@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidCommand = errors.New("Invalid command specified")
+	ErrNoKeys         = errors.New("The command has no key arguments")
+)
+
+// COMMAND [COUNT | INFO [name ...] | DOCS [name ...] | GETKEYS command [arg ...]]
+func (s *Server) command(cmd Command) {
+	if len(cmd.Args) == 0 {
+		specs := Commands()
+		cmd.logError(cmd.Client.Writer.WriteArray(len(specs)))
+		for _, spec := range specs {
+			commandInfo(cmd, spec)
+		}
+		return
+	}
+
+	switch sub := strings.ToUpper(cmd.Args[0]); sub {
+	case "COUNT":
+		if len(cmd.Args) != 1 {
+			cmd.error(wrongArity("command|count"))
+			return
+		}
+		cmd.integer(len(commandTable))
+	case "INFO":
+		specs := Commands()
+		if len(cmd.Args) > 1 {
+			specs = specs[:0]
+			for _, name := range cmd.Args[1:] {
+				spec, _ := LookupCommand(name)
+				specs = append(specs, spec)
+			}
+		}
+		cmd.logError(cmd.Client.Writer.WriteArray(len(specs)))
+		for _, spec := range specs {
+			if spec == nil {
+				cmd.null()
+				continue
+			}
+			commandInfo(cmd, spec)
+		}
+	case "DOCS":
+		specs := Commands()
+		if len(cmd.Args) > 1 {
+			specs = specs[:0]
+			for _, name := range cmd.Args[1:] {
+				if spec, ok := LookupCommand(name); ok {
+					specs = append(specs, spec)
+				}
+			}
+		}
+		writer := cmd.Client.Writer
+		cmd.logError(writer.WriteMap(len(specs)))
+		for _, spec := range specs {
+			cmd.logError(writer.WriteBulkString(strings.ToLower(spec.Name)))
+			cmd.logError(writer.WriteMap(2))
+			cmd.logError(writer.WriteBulkString("summary"))
+			cmd.logError(writer.WriteBulkString(spec.Summary))
+			cmd.logError(writer.WriteBulkString("group"))
+			cmd.logError(writer.WriteBulkString(spec.Group))
+		}
+	case "GETKEYS":
+		if len(cmd.Args) < 2 {
+			cmd.error(wrongArity("command|getkeys"))
+			return
+		}
+		spec, ok := LookupCommand(cmd.Args[1])
+		if !ok {
+			cmd.error(ErrInvalidCommand)
+			return
+		}
+		args := cmd.Args[2:]
+		if !spec.checkArity(len(args) + 1) {
+			cmd.error(errors.New("Invalid number of arguments specified for command"))
+			return
+		}
+		keys := spec.Keys(args)
+		if len(keys) == 0 {
+			cmd.error(ErrNoKeys)
+			return
+		}
+		cmd.array(keys)
+	default:
+		cmd.error(unknownSubcommand(CMD_COMMAND, sub))
+	}
+}
+
+// Write the reply of COMMAND INFO for a single command.
+func commandInfo(cmd Command, spec *CommandSpec) {
+	writer := cmd.Client.Writer
+	cmd.logError(writer.WriteArray(10))
+	cmd.logError(writer.WriteBulkString(strings.ToLower(spec.Name)))
+	cmd.integer(spec.Arity)
+
+	flags := spec.FlagNames()
+	cmd.logError(writer.WriteSet(len(flags)))
+	for _, flag := range flags {
+		cmd.logError(writer.WriteSimpleString(flag))
+	}
+
+	cmd.integer(spec.FirstKey)
+	cmd.integer(spec.LastKey)
+	cmd.integer(spec.Step)
+
+	categories := spec.ACLCategories()
+	cmd.logError(writer.WriteSet(len(categories)))
+	for _, category := range categories {
+		cmd.logError(writer.WriteSimpleString("@" + category))
+	}
+
+	// Tips, key specifications and subcommands
+	cmd.array(nil)
+	cmd.array(nil)
+	cmd.array(nil)
+}
+
+// SHUTDOWN [NOSAVE | SAVE] [NOW] [FORCE] [ABORT]
+//
+// Shut down the server. tanDB has no persistence yet, so SAVE fails
+// unless FORCE is given, and NOSAVE has no effect. NOW closes every
+// connection without waiting for running commands. As no shutdown can
+// be pending once it has begun, ABORT always fails.
+func (s *Server) shutdown(cmd Command) {
+	var save, noSave, now, force, abort bool
+	for _, arg := range cmd.Args {
+		switch strings.ToUpper(arg) {
+		case "SAVE":
+			save = true
+		case "NOSAVE":
+			noSave = true
+		case "NOW":
+			now = true
+		case "FORCE":
+			force = true
+		case "ABORT":
+			abort = true
+		default:
+			cmd.error(ErrSyntax)
+			return
+		}
+	}
+	if (save && noSave) || (abort && (save || noSave || now || force)) {
+		cmd.error(ErrSyntax)
+		return
+	}
+	if abort {
+		cmd.error(errors.New("No shutdown in progress."))
+		return
+	}
+	if save && !force {
+		logf(LogWarning, "unable to save before shutdown: persistence is not configured")
+		cmd.error(errors.New("Errors trying to SHUTDOWN. Check logs."))
+		return
+	}
+
+	timeout := s.ShutdownTimeout()
+	if now {
+		timeout = 0
+	}
+	logf(LogNotice, "shutdown requested by client %d", cmd.Client.ID)
+	// The shutdown waits for this client to finish, so it has to
+	// run outside of the command handler
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := s.Shutdown(ctx); err != nil {
+			logf(LogWarning, "unable to shut down gracefully: %v", err)
+		}
+	}()
+}
+
+// CONFIG GET parameter [parameter ...] | SET parameter value
+//
+//	[parameter value ...] | RESETSTAT | REWRITE
+func (s *Server) config(cmd Command) {
+	sub := strings.ToUpper(cmd.Args[0])
+	args := cmd.Args[1:]
+
+	switch sub {
+	case "GET":
+		if len(args) == 0 {
+			cmd.error(wrongArity("config|get"))
+			return
+		}
+		matched := make(map[string]string)
+		for _, pattern := range args {
+			for name, value := range s.Config.Match(pattern) {
+				matched[name] = value
+			}
+		}
+		names := make([]string, 0, len(matched))
+		for name := range matched {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		writer := cmd.Client.Writer
+		cmd.logError(writer.WriteMap(len(names)))
+		for _, name := range names {
+			cmd.logError(writer.WriteBulkString(name))
+			cmd.logError(writer.WriteBulkString(matched[name]))
+		}
+	case "SET":
+		if len(args) == 0 || len(args)%2 != 0 {
+			cmd.error(wrongArity("config|set"))
+			return
+		}
+		pairs := make([][2]string, 0, len(args)/2)
+		for i := 0; i < len(args); i += 2 {
+			pairs = append(pairs, [2]string{args[i], args[i+1]})
+		}
+		if err := s.Config.Set(pairs); err != nil {
+			// The message starts with the uppercase command name
+			cmd.error(fmt.Errorf("ERR %w", err))
+			return
+		}
+		cmd.ok()
+	case "RESETSTAT":
+		if len(args) != 0 {
+			cmd.error(wrongArity("config|resetstat"))
+			return
+		}
+		s.ResetStats()
+		cmd.ok()
+	case "REWRITE":
+		if len(args) != 0 {
+			cmd.error(wrongArity("config|rewrite"))
+			return
+		}
+		if err := s.Config.Rewrite(); err != nil {
+			logf(LogWarning, "unable to rewrite the config file: %v", err)
+			cmd.error(err)
+			return
+		}
+		cmd.ok()
+	default:
+		cmd.error(unknownSubcommand(CMD_CONFIG, sub))
+	}
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Devansh3712/tandb/store"
+)
+
+var (
+	ErrBitOffset    = errors.New("bit offset is not an integer or out of range")
+	ErrBitValue     = errors.New("bit is not an integer or out of range")
+	ErrBitFieldType = errors.New("Invalid bitfield type. Use something like i16 u8. Note that u64 is not supported but i64 is.")
+)
+
+// Check that a bit offset addresses a byte within the maximum length
+// of a string.
+func (s *Server) checkBitOffset(offset int) error {
+	if offset < 0 || offset/8 >= s.Config.Int("proto-max-bulk-len") {
+		return ErrBitOffset
+	}
+	return nil
+}
+
+func (s *Server) parseBitOffset(arg string) (int, error) {
+	offset, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, ErrBitOffset
+	}
+	return offset, s.checkBitOffset(offset)
+}
+
+// Parse the optional range arguments of BITCOUNT and BITPOS, which end
+// with the BYTE or BIT unit.
+func parseBitRange(args []string) (store.BitRange, error) {
+	r := store.FullRange
+	var err error
+	if len(args) > 0 {
+		if r.Start, err = strconv.Atoi(args[0]); err != nil {
+			return r, ErrNotInteger
+		}
+	}
+	if len(args) > 1 {
+		if r.End, err = strconv.Atoi(args[1]); err != nil {
+			return r, ErrNotInteger
+		}
+	}
+	if len(args) > 2 {
+		switch strings.ToUpper(args[2]) {
+		case "BYTE":
+		case "BIT":
+			r.Bits = true
+		default:
+			return r, ErrSyntax
+		}
+	}
+	if len(args) > 3 {
+		return r, ErrSyntax
+	}
+	return r, nil
+}
+
+// SETBIT key offset value
+func (s *Server) setBit(cmd Command) {
+	offset, err := s.parseBitOffset(cmd.Args[1])
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	if cmd.Args[2] != "0" && cmd.Args[2] != "1" {
+		cmd.error(ErrBitValue)
+		return
+	}
+	previous, err := s.DB.SetBit(cmd.Args[0], offset, int(cmd.Args[2][0]-'0'))
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.integer(previous)
+}
+
+// GETBIT key offset
+func (s *Server) getBit(cmd Command) {
+	offset, err := s.parseBitOffset(cmd.Args[1])
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	bit, err := s.DB.GetBit(cmd.Args[0], offset)
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.integer(bit)
+}
+
+// BITCOUNT key [start end [BYTE | BIT]]
+func (s *Server) bitCount(cmd Command) {
+	// A start offset needs an end offset
+	if len(cmd.Args) == 2 {
+		cmd.error(ErrSyntax)
+		return
+	}
+	r, err := parseBitRange(cmd.Args[1:])
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	count, err := s.DB.BitCount(cmd.Args[0], r)
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.integer(count)
+}
+
+// BITPOS key bit [start [end [BYTE | BIT]]]
+func (s *Server) bitPos(cmd Command) {
+	if cmd.Args[1] != "0" && cmd.Args[1] != "1" {
+		cmd.error(errors.New("The bit argument must be 1 or 0."))
+		return
+	}
+	r, err := parseBitRange(cmd.Args[2:])
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	pos, err := s.DB.BitPos(cmd.Args[0], int(cmd.Args[1][0]-'0'), r, len(cmd.Args) > 3)
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.integer(pos)
+}
+
+// BITOP <AND | OR | XOR | NOT> destkey key [key ...]
+func (s *Server) bitOp(cmd Command) {
+	var op int
+	switch strings.ToUpper(cmd.Args[0]) {
+	case "AND":
+		op = store.BitAnd
+	case "OR":
+		op = store.BitOr
+	case "XOR":
+		op = store.BitXor
+	case "NOT":
+		op = store.BitNot
+		if len(cmd.Args) != 3 {
+			cmd.error(errors.New("ERR BITOP NOT must be called with a single source key."))
+			return
+		}
+	default:
+		cmd.error(ErrSyntax)
+		return
+	}
+	length, err := s.DB.BitOp(op, cmd.Args[1], cmd.Args[2:])
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.integer(length)
+}
+
+// Parse the encoding and the offset of a BITFIELD operation. Offsets
+// prefixed with # are multiplied by the width of the field.
+func (s *Server) parseBitField(encoding, offset string) (store.BitFieldOp, error) {
+	var op store.BitFieldOp
+	if len(encoding) < 2 {
+		return op, ErrBitFieldType
+	}
+	switch encoding[0] {
+	case 'i', 'I':
+		op.Signed = true
+	case 'u', 'U':
+	default:
+		return op, ErrBitFieldType
+	}
+	width, err := strconv.Atoi(encoding[1:])
+	if err != nil || width < 1 || width > 64 || (!op.Signed && width == 64) {
+		return op, ErrBitFieldType
+	}
+	op.Bits = width
+
+	multiplier := 1
+	if rest, ok := strings.CutPrefix(offset, "#"); ok {
+		offset, multiplier = rest, width
+	}
+	n, err := strconv.Atoi(offset)
+	if err != nil || n < 0 || n > math.MaxInt/multiplier-width {
+		return op, ErrBitOffset
+	}
+	op.Offset = n * multiplier
+	// The whole field must fit within the maximum length of a string
+	return op, s.checkBitOffset(op.Offset + width - 1)
+}
+
+// Parse the operations of BITFIELD, or of BITFIELD_RO which only allows
+// GET operations.
+func (s *Server) parseBitFieldOps(args []string, readonly bool) ([]store.BitFieldOp, error) {
+	var ops []store.BitFieldOp
+	overflow := store.OverflowWrap
+	for i := 0; i < len(args); {
+		kind := strings.ToUpper(args[i])
+		if readonly && kind != "GET" {
+			return nil, errors.New("ERR BITFIELD_RO only supports the GET subcommand")
+		}
+
+		switch kind {
+		case "OVERFLOW":
+			if i+1 >= len(args) {
+				return nil, ErrSyntax
+			}
+			switch strings.ToUpper(args[i+1]) {
+			case "WRAP":
+				overflow = store.OverflowWrap
+			case "SAT":
+				overflow = store.OverflowSat
+			case "FAIL":
+				overflow = store.OverflowFail
+			default:
+				return nil, errors.New("Invalid OVERFLOW type specified")
+			}
+			i += 2
+		case "GET", "SET", "INCRBY":
+			arity := 3
+			if kind != "GET" {
+				arity = 4
+			}
+			if i+arity > len(args) {
+				return nil, ErrSyntax
+			}
+			op, err := s.parseBitField(args[i+1], args[i+2])
+			if err != nil {
+				return nil, err
+			}
+			switch kind {
+			case "GET":
+				op.Kind = store.BitFieldGet
+			case "SET":
+				op.Kind = store.BitFieldSet
+			case "INCRBY":
+				op.Kind = store.BitFieldIncrBy
+			}
+			if kind != "GET" {
+				if op.Value, err = strconv.ParseInt(args[i+3], 10, 64); err != nil {
+					return nil, ErrNotInteger
+				}
+			}
+			op.Overflow = overflow
+			ops = append(ops, op)
+			i += arity
+		default:
+			return nil, ErrSyntax
+		}
+	}
+	return ops, nil
+}
+
+// BITFIELD key [GET encoding offset | [OVERFLOW <WRAP | SAT | FAIL>]
+// <SET encoding offset value | INCRBY encoding offset increment> ...]
+func (s *Server) bitField(cmd Command) {
+	s.runBitField(cmd, false)
+}
+
+// BITFIELD_RO key [GET encoding offset ...]
+func (s *Server) bitFieldRO(cmd Command) {
+	s.runBitField(cmd, true)
+}
+
+func (s *Server) runBitField(cmd Command, readonly bool) {
+	ops, err := s.parseBitFieldOps(cmd.Args[1:], readonly)
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	results, err := s.DB.BitField(cmd.Args[0], ops)
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.logError(cmd.Client.Writer.WriteArray(len(results)))
+	for _, result := range results {
+		if result == nil {
+			cmd.null()
+			continue
+		}
+		cmd.logError(cmd.Client.Writer.WriteInteger(*result))
+	}
+}
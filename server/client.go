@@ -0,0 +1,192 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Devansh3712/tandb/acl"
+	"github.com/Devansh3712/tandb/resp"
+)
+
+// Client flags
+const (
+	ClientNoEvict = 1 << iota
+	ClientCloseAfterReply
+	// The connection streams the commands run by the server
+	ClientMonitor
+)
+
+var clientID atomic.Int64
+
+// Client holds the state of a single connection. Each client has
+// its own reader and writer, which are only used by the goroutine
+// serving the connection.
+type Client struct {
+	ID        int64
+	Addr      string
+	LocalAddr string
+	Created   time.Time
+	Conn      net.Conn
+	Listener  *Listener
+	Reader    *resp.Reader
+	Writer    *resp.Writer
+	// Counts the error replies sent to the client, if set
+	errorStats *ErrorStats
+
+	// The fields below are also read by other clients, for
+	// example through CLIENT LIST, and are guarded by the mutex
+	Mutex           *sync.RWMutex
+	Name            string
+	User            string
+	Authenticated   bool
+	DB              int
+	Flags           int
+	Protocol        int
+	LastCommand     string
+	LastInteraction time.Time
+}
+
+func NewClient(conn net.Conn, listener *Listener) *Client {
+	now := time.Now()
+	addr, localAddr := conn.RemoteAddr().String(), conn.LocalAddr().String()
+	// Clients of Unix sockets have no address of their own
+	if listener.Config.Network == "unix" {
+		addr = listener.Config.Addr + ":0"
+		localAddr = addr
+	}
+	return &Client{
+		ID:              clientID.Add(1),
+		Addr:            addr,
+		LocalAddr:       localAddr,
+		Created:         now,
+		Conn:            conn,
+		Listener:        listener,
+		Reader:          resp.NewReader(conn),
+		Writer:          resp.NewWriter(conn),
+		Mutex:           &sync.RWMutex{},
+		User:            acl.DefaultUser,
+		Protocol:        resp.RESP2,
+		LastInteraction: now,
+	}
+}
+
+// Read the next command sent by the client.
+func (c *Client) ReadCommand() (Command, error) {
+	args, err := c.Reader.ReadCommand()
+	if err != nil {
+		return Command{}, err
+	}
+	return Command{Value: args[0], Args: args[1:], Client: c}, nil
+}
+
+// Flush any pending replies and close the connection.
+func (c *Client) Close() error {
+	c.Writer.Flush()
+	return c.Conn.Close()
+}
+
+// Record the command the client is about to run.
+func (c *Client) touch(name string) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	c.LastCommand = strings.ToLower(name)
+	c.LastInteraction = time.Now()
+}
+
+// Switch the user of the client after a successful authentication.
+func (c *Client) SetUser(user string) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	c.User = user
+	c.Authenticated = true
+}
+
+// Returns the user of the client, and whether it has authenticated.
+func (c *Client) GetUser() (string, bool) {
+	c.Mutex.RLock()
+	defer c.Mutex.RUnlock()
+
+	return c.User, c.Authenticated
+}
+
+func (c *Client) SetName(name string) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	c.Name = name
+}
+
+func (c *Client) GetName() string {
+	c.Mutex.RLock()
+	defer c.Mutex.RUnlock()
+
+	return c.Name
+}
+
+// Switch the protocol used for the replies of the client.
+func (c *Client) SetProtocol(protocol int) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	c.Protocol = protocol
+	c.Writer.Protocol = protocol
+}
+
+func (c *Client) SetFlag(flag int, on bool) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	if on {
+		c.Flags |= flag
+	} else {
+		c.Flags &^= flag
+	}
+}
+
+func (c *Client) HasFlag(flag int) bool {
+	c.Mutex.RLock()
+	defer c.Mutex.RUnlock()
+
+	return c.Flags&flag != 0
+}
+
+// Returns a description of the client in the format of CLIENT LIST.
+func (c *Client) Info() string {
+	c.Mutex.RLock()
+	defer c.Mutex.RUnlock()
+
+	flags := ""
+	if c.Flags&ClientMonitor != 0 {
+		flags += "O"
+	}
+	if c.Flags&ClientNoEvict != 0 {
+		flags += "e"
+	}
+	if flags == "" {
+		flags = "N"
+	}
+	now := time.Now()
+	return fmt.Sprintf(
+		"id=%d addr=%s laddr=%s name=%s age=%d idle=%d flags=%s db=%d cmd=%s user=%s resp=%d",
+		c.ID, c.Addr, c.LocalAddr, c.Name,
+		int(now.Sub(c.Created).Seconds()), int(now.Sub(c.LastInteraction).Seconds()),
+		flags, c.DB, c.LastCommand, c.User, c.Protocol,
+	)
+}
+
+// Check if a client name is valid. Names cannot contain spaces,
+// newlines or other special characters.
+func validClientName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		if name[i] < '!' || name[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
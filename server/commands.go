@@ -1,19 +1,86 @@
 package server
 
 import (
-	"errors"
-	"log"
+	"strings"
 )
 
-var ErrNotEnoughArgs = errors.New("not enough arguments for command")
-
-func (c *Command) write(msg string) {
-	_, err := c.Conn.Write([]byte(msg + "\n"))
+func (c *Command) logError(err error) {
 	if err != nil {
-		log.Printf("unable to write to connection: %v", err)
+		logf(LogVerbose, "unable to write to connection: %v", err)
 	}
 }
 
+func (c *Command) ok() {
+	c.logError(c.Client.Writer.WriteSimpleString("OK"))
+}
+
+// Reply with an error. Errors are prefixed with the generic ERR
+// code unless the message already starts with an upper case code.
 func (c *Command) error(err error) {
-	c.write("[ERROR] " + err.Error())
+	msg := err.Error()
+	code, _, _ := strings.Cut(msg, " ")
+	if code == "" || strings.ToUpper(code) != code {
+		msg = "ERR " + msg
+	}
+	if c.Client.errorStats != nil {
+		c.Client.errorStats.add(msg)
+	}
+	c.logError(c.Client.Writer.WriteError(msg))
+}
+
+func (c *Command) integer(n int) {
+	c.logError(c.Client.Writer.WriteInteger(int64(n)))
+}
+
+func (c *Command) bulk(b []byte) {
+	c.logError(c.Client.Writer.WriteBulk(b))
+}
+
+func (c *Command) null() {
+	c.logError(c.Client.Writer.WriteNull())
+}
+
+// Reply with a boolean. RESP2 clients receive 1 for true
+// and 0 for false. Only replies which Redis sends as booleans
+// under RESP3, such as EXISTS and SISMEMBER, use it.
+func (c *Command) boolean(ok bool) {
+	c.logError(c.Client.Writer.WriteBool(ok))
+}
+
+// Reply with 1 for true and 0 for false, whatever the protocol.
+func (c *Command) integerBool(ok bool) {
+	if ok {
+		c.integer(1)
+		return
+	}
+	c.integer(0)
+}
+
+// Reply with an array of bulk strings.
+func (c *Command) array(elements []string) {
+	c.logError(c.Client.Writer.WriteArray(len(elements)))
+	for _, element := range elements {
+		c.logError(c.Client.Writer.WriteBulkString(element))
+	}
+}
+
+// Reply with an unordered set of bulk strings.
+func (c *Command) set(elements []string) {
+	c.logError(c.Client.Writer.WriteSet(len(elements)))
+	for _, element := range elements {
+		c.logError(c.Client.Writer.WriteBulkString(element))
+	}
+}
+
+// Reply with an array of bulk strings, where nil
+// values are written as null bulk strings.
+func (c *Command) bulkArray(values [][]byte) {
+	c.logError(c.Client.Writer.WriteArray(len(values)))
+	for _, value := range values {
+		if value == nil {
+			c.null()
+			continue
+		}
+		c.bulk(value)
+	}
 }
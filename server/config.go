@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Devansh3712/tandb/acl"
+	"github.com/Devansh3712/tandb/config"
+)
+
+// Create a server from its configuration. A plaintext listener is
+// added for every bind address when port is not 0, a TLS listener
+// for every bind address when tls-port is not 0, and a Unix socket
+// listener when unixsocket is set. Metrics are served over HTTP
+// when metrics-port is not 0.
+func NewServerFromConfig(cfg *config.Config) (*Server, error) {
+	s := NewServer("")
+	s.Config = cfg
+	s.ACLFile = cfg.Get("aclfile")
+	if err := s.watchConfig(); err != nil {
+		return nil, err
+	}
+
+	hosts := strings.Fields(cfg.Get("bind"))
+	// No bind address listens on every interface
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+	if port := cfg.Get("port"); port != "0" {
+		for _, host := range hosts {
+			s.AddListener(ListenerConfig{Network: "tcp", Addr: net.JoinHostPort(host, port)})
+		}
+	}
+	if port := cfg.Get("tls-port"); port != "0" {
+		tlsConfig := &TLSConfig{
+			CertFile:        cfg.Get("tls-cert-file"),
+			KeyFile:         cfg.Get("tls-key-file"),
+			CAFile:          cfg.Get("tls-ca-cert-file"),
+			AuthClients:     cfg.Get("tls-auth-clients"),
+			Ciphers:         cfg.Get("tls-ciphers"),
+			MinVersion:      cfg.Get("tls-min-version"),
+			AuthClientsUser: cfg.Get("tls-auth-clients-user"),
+		}
+		for _, host := range hosts {
+			s.AddListener(ListenerConfig{Network: "tcp", Addr: net.JoinHostPort(host, port), TLS: tlsConfig})
+		}
+	}
+	if port := cfg.Get("metrics-port"); port != "0" {
+		s.MetricsAddr = net.JoinHostPort(cfg.Get("metrics-bind"), port)
+	}
+	if path := cfg.Get("unixsocket"); path != "" {
+		perm, _ := strconv.ParseUint(cfg.Get("unixsocketperm"), 8, 32)
+		s.AddListener(ListenerConfig{Network: "unix", Addr: path, Perm: os.FileMode(perm)})
+	}
+	return s, nil
+}
+
+// Apply the mutable parameters which are not read from the config
+// every time they are used, and their changes at runtime.
+func (s *Server) watchConfig() error {
+	hooks := map[string]func(string) error{
+		"loglevel":           setLogLevel,
+		"requirepass":        s.setRequirePass,
+		"ttl-sweep-interval": s.restartSweeper,
+		"ttl-sweep-budget":   s.restartSweeper,
+		"slowlog-log-slower-than": func(value string) error {
+			threshold, _ := strconv.ParseInt(value, 10, 64)
+			s.Slowlog.SetThreshold(threshold)
+			return nil
+		},
+		"slowlog-max-len": func(value string) error {
+			maxLen, _ := strconv.Atoi(value)
+			s.Slowlog.SetMaxLen(maxLen)
+			return nil
+		},
+	}
+	for name, hook := range hooks {
+		s.Config.OnChange(name, hook)
+		if err := hook(s.Config.Get(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restart the sweeper of expired keys with the current interval
+// and budget, if it is running.
+func (s *Server) restartSweeper(string) error {
+	s.Mutex.Lock()
+	running := s.stopTTL != nil
+	s.Mutex.Unlock()
+	if running {
+		s.startSweeper()
+	}
+	return nil
+}
+
+// Set the password of the default user. An empty password lets
+// clients use the default user without authenticating.
+func (s *Server) setRequirePass(password string) error {
+	rules := []string{"nopass"}
+	if password != "" {
+		rules = []string{"resetpass", ">" + password}
+	}
+	return s.ACL.SetUser(acl.DefaultUser, rules)
+}
+
+// Time given to clients to finish their commands when the server
+// is shut down.
+func (s *Server) ShutdownTimeout() time.Duration {
+	return time.Duration(s.Config.Int("shutdown-timeout")) * time.Second
+}
+
+// Reset the statistics reported by the server.
+func (s *Server) ResetStats() {
+	for _, listener := range s.Listeners {
+		listener.Accepted.Store(0)
+		listener.Rejected.Store(0)
+		listener.Commands.Store(0)
+	}
+	s.DB.Stats.Reset()
+	for _, stats := range s.commandStats {
+		stats.reset()
+	}
+	s.errorStats.reset()
+}
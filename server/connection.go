@@ -0,0 +1,325 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Devansh3712/tandb/acl"
+	"github.com/Devansh3712/tandb/resp"
+)
+
+const Version = "0.1.0"
+
+var (
+	ErrNoProto   = errors.New("NOPROTO unsupported protocol version")
+	ErrWrongPass = errors.New("WRONGPASS invalid username-password pair or user is disabled.")
+	ErrSyntax    = errors.New("syntax error")
+	ErrNoClient  = errors.New("No such client")
+	ErrNoAuth    = errors.New("NOAUTH Authentication required.")
+
+	ErrHelloNoAuth = errors.New("NOAUTH HELLO must be called with the client already authenticated, otherwise the HELLO <proto> AUTH <user> <pass> option can be used to authenticate the client and select the RESP protocol version at the same time")
+
+	ErrNoPassword        = errors.New("AUTH <password> called without any password configured for the default user. Are you sure your configuration is correct?")
+	ErrInvalidClientName = errors.New("Client names cannot contain spaces, newlines or special characters.")
+)
+
+// Authenticate a client as a user. Failed attempts are
+// recorded in the ACL log.
+func (s *Server) authenticate(client *Client, user, password string) error {
+	if !s.ACL.Authenticate(user, password) {
+		s.ACL.AddLogEntry(acl.ReasonAuth, "AUTH", user, client.Info())
+		return ErrWrongPass
+	}
+	client.SetUser(user)
+	return nil
+}
+
+// AUTH [username] password
+//
+// Authenticate the connection. Without a username the
+// default user is used.
+func (s *Server) auth(cmd Command) {
+	if len(cmd.Args) > 2 {
+		cmd.error(ErrSyntax)
+		return
+	}
+	user, password := acl.DefaultUser, cmd.Args[0]
+	if len(cmd.Args) == 2 {
+		user, password = cmd.Args[0], cmd.Args[1]
+	} else if s.ACL.DefaultAuthenticated() {
+		cmd.error(ErrNoPassword)
+		return
+	}
+	if err := s.authenticate(cmd.Client, user, password); err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.ok()
+}
+
+// HELLO [protover [AUTH username password] [SETNAME clientname]]
+//
+// Switch the protocol of the connection and reply with a map
+// describing the server and the connection.
+func (s *Server) hello(cmd Command) {
+	client := cmd.Client
+	protocol := client.Writer.Protocol
+	var name *string
+
+	if len(cmd.Args) > 0 {
+		version, err := strconv.Atoi(cmd.Args[0])
+		if err != nil {
+			cmd.error(errors.New("Protocol version is not an integer or out of range"))
+			return
+		}
+		if version != resp.RESP2 && version != resp.RESP3 {
+			cmd.error(ErrNoProto)
+			return
+		}
+		protocol = version
+	}
+
+	for i := 1; i < len(cmd.Args); i++ {
+		switch strings.ToUpper(cmd.Args[i]) {
+		case "AUTH":
+			if i+2 >= len(cmd.Args) {
+				cmd.error(ErrSyntax)
+				return
+			}
+			if err := s.authenticate(client, cmd.Args[i+1], cmd.Args[i+2]); err != nil {
+				cmd.error(err)
+				return
+			}
+			i += 2
+		case "SETNAME":
+			if i+1 >= len(cmd.Args) {
+				cmd.error(ErrSyntax)
+				return
+			}
+			name = &cmd.Args[i+1]
+			if !validClientName(*name) {
+				cmd.error(ErrInvalidClientName)
+				return
+			}
+			i++
+		default:
+			cmd.error(ErrSyntax)
+			return
+		}
+	}
+
+	// HELLO bypasses the authentication check so that it can carry
+	// AUTH, but is refused to unauthenticated clients without it
+	if _, authenticated := client.GetUser(); !authenticated {
+		cmd.error(ErrHelloNoAuth)
+		return
+	}
+
+	if name != nil {
+		client.SetName(*name)
+	}
+	client.SetProtocol(protocol)
+
+	writer := client.Writer
+	cmd.logError(writer.WriteMap(7))
+	cmd.logError(writer.WriteBulkString("server"))
+	cmd.logError(writer.WriteBulkString("tandb"))
+	cmd.logError(writer.WriteBulkString("version"))
+	cmd.logError(writer.WriteBulkString(Version))
+	cmd.logError(writer.WriteBulkString("proto"))
+	cmd.integer(protocol)
+	cmd.logError(writer.WriteBulkString("id"))
+	cmd.integer(int(client.ID))
+	cmd.logError(writer.WriteBulkString("mode"))
+	cmd.logError(writer.WriteBulkString("standalone"))
+	cmd.logError(writer.WriteBulkString("role"))
+	cmd.logError(writer.WriteBulkString("master"))
+	cmd.logError(writer.WriteBulkString("modules"))
+	cmd.array(nil)
+}
+
+// CLIENT ID | INFO | LIST | SETNAME | GETNAME | KILL | NO-EVICT
+func (s *Server) client(cmd Command) {
+	client := cmd.Client
+	sub := strings.ToUpper(cmd.Args[0])
+	args := cmd.Args[1:]
+
+	switch sub {
+	case "ID":
+		if len(args) != 0 {
+			cmd.error(wrongArity("client|id"))
+			return
+		}
+		cmd.integer(int(client.ID))
+	case "INFO":
+		if len(args) != 0 {
+			cmd.error(wrongArity("client|info"))
+			return
+		}
+		cmd.logError(client.Writer.WriteVerbatim("txt", client.Info()+"\n"))
+	case "LIST":
+		s.clientListCmd(cmd, args)
+	case "SETNAME":
+		if len(args) != 1 {
+			cmd.error(wrongArity("client|setname"))
+			return
+		}
+		if !validClientName(args[0]) {
+			cmd.error(ErrInvalidClientName)
+			return
+		}
+		client.SetName(args[0])
+		cmd.ok()
+	case "GETNAME":
+		if len(args) != 0 {
+			cmd.error(wrongArity("client|getname"))
+			return
+		}
+		name := client.GetName()
+		if name == "" {
+			cmd.null()
+			return
+		}
+		cmd.bulk([]byte(name))
+	case "KILL":
+		s.clientKill(cmd, args)
+	case "NO-EVICT":
+		if len(args) != 1 {
+			cmd.error(wrongArity("client|no-evict"))
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "ON":
+			client.SetFlag(ClientNoEvict, true)
+		case "OFF":
+			client.SetFlag(ClientNoEvict, false)
+		default:
+			cmd.error(ErrSyntax)
+			return
+		}
+		cmd.ok()
+	default:
+		cmd.error(unknownSubcommand(CMD_CLIENT, sub))
+	}
+}
+
+// CLIENT LIST [TYPE normal|master|replica|pubsub] [ID client-id ...]
+func (s *Server) clientListCmd(cmd Command, args []string) {
+	filter := func(*Client) bool { return true }
+
+	if len(args) == 2 && strings.ToUpper(args[0]) == "TYPE" {
+		switch strings.ToLower(args[1]) {
+		case "normal":
+		case "master", "replica", "slave", "pubsub":
+			// There are no replication or pub/sub clients
+			filter = func(*Client) bool { return false }
+		default:
+			cmd.error(fmt.Errorf("Unknown client type '%s'", args[1]))
+			return
+		}
+	} else if len(args) >= 2 && strings.ToUpper(args[0]) == "ID" {
+		ids := make(map[int64]bool)
+		for _, arg := range args[1:] {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil || id <= 0 {
+				cmd.error(fmt.Errorf("Invalid client ID"))
+				return
+			}
+			ids[id] = true
+		}
+		filter = func(c *Client) bool { return ids[c.ID] }
+	} else if len(args) != 0 {
+		cmd.error(ErrSyntax)
+		return
+	}
+
+	var list strings.Builder
+	for _, client := range s.ClientList() {
+		if filter(client) {
+			list.WriteString(client.Info())
+			list.WriteByte('\n')
+		}
+	}
+	cmd.logError(cmd.Client.Writer.WriteVerbatim("txt", list.String()))
+}
+
+// CLIENT KILL addr:port
+// CLIENT KILL [ID client-id] [ADDR addr:port] [LADDR addr:port]
+//
+//	[USER username] [SKIPME yes|no]
+func (s *Server) clientKill(cmd Command, args []string) {
+	if len(args) == 0 {
+		cmd.error(wrongArity("client|kill"))
+		return
+	}
+
+	// Old form, killing a single client by its address
+	if len(args) == 1 {
+		killed := s.KillClients(cmd.Client, func(c *Client) bool {
+			return c.Addr == args[0]
+		})
+		if killed == 0 {
+			cmd.error(ErrNoClient)
+			return
+		}
+		cmd.ok()
+		return
+	}
+
+	if len(args)%2 != 0 {
+		cmd.error(ErrSyntax)
+		return
+	}
+	var filters []func(*Client) bool
+	skipMe := true
+	for i := 0; i < len(args); i += 2 {
+		value := args[i+1]
+		switch strings.ToUpper(args[i]) {
+		case "ID":
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || id <= 0 {
+				cmd.error(fmt.Errorf("client-id should be greater than 0"))
+				return
+			}
+			filters = append(filters, func(c *Client) bool { return c.ID == id })
+		case "ADDR":
+			filters = append(filters, func(c *Client) bool { return c.Addr == value })
+		case "LADDR":
+			filters = append(filters, func(c *Client) bool { return c.LocalAddr == value })
+		case "USER":
+			filters = append(filters, func(c *Client) bool {
+				c.Mutex.RLock()
+				defer c.Mutex.RUnlock()
+				return c.User == value
+			})
+		case "SKIPME":
+			switch strings.ToLower(value) {
+			case "yes":
+				skipMe = true
+			case "no":
+				skipMe = false
+			default:
+				cmd.error(ErrSyntax)
+				return
+			}
+		default:
+			cmd.error(ErrSyntax)
+			return
+		}
+	}
+
+	killed := s.KillClients(cmd.Client, func(c *Client) bool {
+		if skipMe && c == cmd.Client {
+			return false
+		}
+		for _, filter := range filters {
+			if !filter(c) {
+				return false
+			}
+		}
+		return true
+	})
+	cmd.integer(killed)
+}
@@ -1,136 +1,444 @@
 package server
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/Devansh3712/tandb/store"
 )
 
 func (s *Server) get(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
+	cmd.value(s.DB.Get(cmd.Args[0]))
+}
+
+// Reply with the value of a key, or null if it does not exist.
+func (c *Command) value(value []byte, err error) {
+	if errors.Is(err, store.ErrKeyNotExists) {
+		c.null()
 		return
 	}
-	result, err := s.DB.Get(cmd.Args[0])
 	if err != nil {
-		cmd.error(err)
+		c.error(err)
 		return
 	}
-	cmd.write(string(result))
+	c.bulk(value)
 }
 
-func (s *Server) set(cmd Command) {
-	if len(cmd.Args) < 2 {
-		cmd.error(ErrNotEnoughArgs)
+// GETDEL key
+func (s *Server) getDel(cmd Command) {
+	cmd.value(s.DB.GetDel(cmd.Args[0]))
+}
+
+// GETSET key value
+func (s *Server) getSet(cmd Command) {
+	cmd.value(s.DB.GetSet(cmd.Args[0], []byte(cmd.Args[1])))
+}
+
+// GETEX key [EX seconds | PX milliseconds | EXAT unix-time-seconds |
+//
+//	PXAT unix-time-milliseconds | PERSIST]
+func (s *Server) getEx(cmd Command) {
+	var at time.Time
+	persist := false
+	// Only one option is allowed, with its argument
+	switch {
+	case len(cmd.Args) == 1:
+	case len(cmd.Args) == 2 && strings.ToUpper(cmd.Args[1]) == "PERSIST":
+		persist = true
+	case len(cmd.Args) == 3:
+		unit := strings.ToUpper(cmd.Args[1])
+		if unit != "EX" && unit != "PX" && unit != "EXAT" && unit != "PXAT" {
+			cmd.error(ErrSyntax)
+			return
+		}
+		var err error
+		if at, err = parseExpiration(cmd, unit, cmd.Args[2]); err != nil {
+			cmd.error(err)
+			return
+		}
+	default:
+		cmd.error(ErrSyntax)
 		return
 	}
-	err := s.DB.Set(cmd.Args[0], []byte(cmd.Args[1]))
+	cmd.value(s.DB.GetEx(cmd.Args[0], at, persist))
+}
+
+var (
+	ErrNotInteger = store.ErrNotInteger
+	ErrNoSuchKey  = errors.New("no such key")
+)
+
+func invalidExpireTime(cmd Command) error {
+	return fmt.Errorf("invalid expire time in '%s' command", strings.ToLower(cmd.Value))
+}
+
+// Convert an expiration in the unit of an option to a Unix time in
+// milliseconds. EX and PX are relative to now in seconds and
+// milliseconds, while EXAT and PXAT are Unix times in seconds and
+// milliseconds.
+func deadline(cmd Command, unit string, n int64, now time.Time) (int64, error) {
+	millis := n
+	if unit == "EX" || unit == "EXAT" {
+		if n > math.MaxInt64/1000 || n < math.MinInt64/1000 {
+			return 0, invalidExpireTime(cmd)
+		}
+		millis = n * 1000
+	}
+	if unit == "EX" || unit == "PX" {
+		if millis > math.MaxInt64-now.UnixMilli() {
+			return 0, invalidExpireTime(cmd)
+		}
+		millis += now.UnixMilli()
+	}
+	return millis, nil
+}
+
+// Parse the positive expiration of SET, SETEX and PSETEX.
+func parseExpiration(cmd Command, unit, arg string) (time.Time, error) {
+	n, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrNotInteger
+	}
+	if n <= 0 {
+		return time.Time{}, invalidExpireTime(cmd)
+	}
+	millis, err := deadline(cmd, unit, n, time.Now())
 	if err != nil {
-		cmd.error(err)
+		return time.Time{}, err
 	}
+	return time.UnixMilli(millis), nil
 }
 
-func (s *Server) setEx(cmd Command) {
-	if len(cmd.Args) < 3 {
-		cmd.error(ErrNotEnoughArgs)
+// SET key value [NX | XX] [GET] [EX seconds | PX milliseconds |
+//
+//	EXAT unix-time-seconds | PXAT unix-time-milliseconds | KEEPTTL]
+func (s *Server) set(cmd Command) {
+	var options store.SetOptions
+	// The expiration option given, as only one is allowed
+	expiry := ""
+
+	for i := 2; i < len(cmd.Args); i++ {
+		switch option := strings.ToUpper(cmd.Args[i]); option {
+		case "NX":
+			if options.XX {
+				cmd.error(ErrSyntax)
+				return
+			}
+			options.NX = true
+		case "XX":
+			if options.NX {
+				cmd.error(ErrSyntax)
+				return
+			}
+			options.XX = true
+		case "GET":
+			options.Get = true
+		case "KEEPTTL":
+			if expiry != "" {
+				cmd.error(ErrSyntax)
+				return
+			}
+			expiry = option
+			options.KeepTTL = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if expiry != "" || i+1 >= len(cmd.Args) {
+				cmd.error(ErrSyntax)
+				return
+			}
+			expiry = option
+			at, err := parseExpiration(cmd, option, cmd.Args[i+1])
+			if err != nil {
+				cmd.error(err)
+				return
+			}
+			options.ExpireAt = at
+			i++
+		default:
+			cmd.error(ErrSyntax)
+			return
+		}
+	}
+
+	previous, existed, err := s.DB.SetWithOptions(cmd.Args[0], []byte(cmd.Args[1]), options)
+	if errors.Is(err, store.ErrWrongType) {
+		cmd.error(err)
+		return
+	}
+	if options.Get {
+		if !existed {
+			cmd.null()
+			return
+		}
+		cmd.bulk(previous)
 		return
 	}
-	ttl, err := strconv.Atoi(cmd.Args[2])
 	if err != nil {
-		cmd.error(err)
+		cmd.null()
+		return
 	}
-	err = s.DB.SetEx(cmd.Args[0], []byte(cmd.Args[1]), time.Duration(ttl))
+	cmd.ok()
+}
+
+// SETNX key value
+func (s *Server) setNX(cmd Command) {
+	cmd.integerBool(s.DB.SetNX(cmd.Args[0], []byte(cmd.Args[1])))
+}
+
+// SETEX key seconds value
+func (s *Server) setEx(cmd Command) {
+	s.setWithExpiration(cmd, "EX")
+}
+
+// PSETEX key milliseconds value
+func (s *Server) pSetEx(cmd Command) {
+	s.setWithExpiration(cmd, "PX")
+}
+
+func (s *Server) setWithExpiration(cmd Command, unit string) {
+	at, err := parseExpiration(cmd, unit, cmd.Args[1])
 	if err != nil {
 		cmd.error(err)
+		return
 	}
+	s.DB.SetWithOptions(cmd.Args[0], []byte(cmd.Args[2]), store.SetOptions{ExpireAt: at})
+	cmd.ok()
 }
 
 func (s *Server) del(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
+	deleted := 0
+	for _, key := range cmd.Args {
+		if err := s.DB.Del(key); err == nil {
+			deleted++
+		}
+	}
+	cmd.integer(deleted)
+}
+
+// RENAME key newkey
+func (s *Server) rename(cmd Command) {
+	if _, err := s.DB.Rename(cmd.Args[0], cmd.Args[1], false); err != nil {
+		cmd.error(ErrNoSuchKey)
 		return
 	}
-	err := s.DB.Del(cmd.Args[0])
+	cmd.ok()
+}
+
+// RENAMENX key newkey
+func (s *Server) renameNX(cmd Command) {
+	ok, err := s.DB.Rename(cmd.Args[0], cmd.Args[1], true)
 	if err != nil {
-		cmd.error(err)
+		cmd.error(ErrNoSuchKey)
+		return
+	}
+	cmd.integerBool(ok)
+}
+
+// COPY source destination [DB destination-db] [REPLACE]
+func (s *Server) copyKey(cmd Command) {
+	replace := false
+	for i := 2; i < len(cmd.Args); i++ {
+		switch strings.ToUpper(cmd.Args[i]) {
+		case "REPLACE":
+			replace = true
+		case "DB":
+			if i+1 >= len(cmd.Args) {
+				cmd.error(ErrSyntax)
+				return
+			}
+			i++
+			db, err := strconv.Atoi(cmd.Args[i])
+			if err != nil {
+				cmd.error(ErrNotInteger)
+				return
+			}
+			// There is a single database
+			if db != 0 {
+				cmd.error(errors.New("ERR DB index is out of range"))
+				return
+			}
+		default:
+			cmd.error(ErrSyntax)
+			return
+		}
+	}
+	if cmd.Args[0] == cmd.Args[1] {
+		cmd.error(errors.New("source and destination objects are the same"))
+		return
 	}
+	cmd.integerBool(s.DB.Copy(cmd.Args[0], cmd.Args[1], replace))
 }
 
 func (s *Server) mGet(cmd Command) {
 	result := s.DB.MGet(cmd.Args)
-	for index, value := range result {
-		cmd.write(fmt.Sprintf("%d) %s", index+1, value))
+	cmd.bulkArray(result)
+}
+
+// Parse the key-value pairs of MSET and MSETNX.
+func parsePairs(cmd Command) ([]store.Pair, bool) {
+	if len(cmd.Args)%2 != 0 {
+		cmd.error(wrongArity(cmd.Value))
+		return nil, false
+	}
+	pairs := make([]store.Pair, 0, len(cmd.Args)/2)
+	for i := 0; i < len(cmd.Args); i += 2 {
+		pairs = append(pairs, store.Pair{Key: cmd.Args[i], Value: []byte(cmd.Args[i+1])})
 	}
+	return pairs, true
 }
 
-func (s *Server) expire(cmd Command) {
-	if len(cmd.Args) < 2 {
-		cmd.error(ErrNotEnoughArgs)
+// MSET key value [key value ...]
+func (s *Server) mSet(cmd Command) {
+	pairs, ok := parsePairs(cmd)
+	if !ok {
+		return
+	}
+	s.DB.MSet(pairs)
+	cmd.ok()
+}
+
+// MSETNX key value [key value ...]
+func (s *Server) mSetNX(cmd Command) {
+	pairs, ok := parsePairs(cmd)
+	if !ok {
 		return
 	}
-	expiration, err := strconv.Atoi(cmd.Args[1])
+	cmd.integerBool(s.DB.MSetNX(pairs))
+}
+
+// EXPIRE key seconds [NX | XX | GT | LT]
+func (s *Server) expire(cmd Command) {
+	s.expireWith(cmd, "EX")
+}
+
+// PEXPIRE key milliseconds [NX | XX | GT | LT]
+func (s *Server) pExpire(cmd Command) {
+	s.expireWith(cmd, "PX")
+}
+
+// EXPIREAT key unix-time-seconds [NX | XX | GT | LT]
+func (s *Server) expireAt(cmd Command) {
+	s.expireWith(cmd, "EXAT")
+}
+
+// PEXPIREAT key unix-time-milliseconds [NX | XX | GT | LT]
+func (s *Server) pExpireAt(cmd Command) {
+	s.expireWith(cmd, "PXAT")
+}
+
+// Set the expiration time of a key, given in the unit of an option
+// of SET. An expiration time in the past deletes the key.
+func (s *Server) expireWith(cmd Command, unit string) {
+	n, err := strconv.ParseInt(cmd.Args[1], 10, 64)
 	if err != nil {
-		cmd.error(err)
+		cmd.error(ErrNotInteger)
+		return
+	}
+
+	conditions := 0
+	for _, arg := range cmd.Args[2:] {
+		switch strings.ToUpper(arg) {
+		case "NX":
+			conditions |= store.ExpireNX
+		case "XX":
+			conditions |= store.ExpireXX
+		case "GT":
+			conditions |= store.ExpireGT
+		case "LT":
+			conditions |= store.ExpireLT
+		default:
+			cmd.error(fmt.Errorf("Unsupported option %s", arg))
+			return
+		}
+	}
+	if conditions&store.ExpireNX != 0 && conditions != store.ExpireNX {
+		cmd.error(errors.New("ERR NX and XX, GT or LT options at the same time are not compatible"))
+		return
+	}
+	if conditions&store.ExpireGT != 0 && conditions&store.ExpireLT != 0 {
+		cmd.error(errors.New("ERR GT and LT options at the same time are not compatible"))
+		return
 	}
-	err = s.DB.Expire(cmd.Args[0], time.Duration(expiration))
+
+	at, err := deadline(cmd, unit, n, time.Now())
 	if err != nil {
 		cmd.error(err)
+		return
 	}
+	ok, err := s.DB.ExpireAt(cmd.Args[0], at, conditions)
+	cmd.integerBool(ok && err == nil)
 }
 
+// KEYS pattern
 func (s *Server) keys(cmd Command) {
-	keys := s.DB.Keys()
-	for index, key := range keys {
-		cmd.write(fmt.Sprintf("%d) %s", index+1, key))
-	}
+	keys := s.DB.Keys(cmd.Args[0])
+	cmd.array(keys)
 }
 
 func (s *Server) exists(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
 	ok := s.DB.Exists(cmd.Args[0])
-	if !ok {
-		cmd.write("FALSE")
-		return
-	}
-	cmd.write("TRUE")
+	cmd.boolean(ok)
+}
+
+// TYPE key
+func (s *Server) keyType(cmd Command) {
+	cmd.logError(cmd.Client.Writer.WriteSimpleString(s.DB.Type(cmd.Args[0])))
 }
 
 func (s *Server) persist(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
-	err := s.DB.Persist(cmd.Args[0])
-	if err != nil {
-		cmd.error(err)
-	}
+	cmd.integerBool(s.DB.Persist(cmd.Args[0]))
 }
 
+// EXPIRETIME key
+//
+// Returns -1 if the key has no expiration time, and -2 if the key
+// does not exist.
 func (s *Server) expireTime(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
-	exp, err := s.DB.ExpireTime(cmd.Args[0])
-	if err != nil {
-		cmd.error(err)
-		return
+	s.expireTimeIn(cmd, time.Second)
+}
+
+// PEXPIRETIME key
+func (s *Server) pExpireTime(cmd Command) {
+	s.expireTimeIn(cmd, time.Millisecond)
+}
+
+func (s *Server) expireTimeIn(cmd Command, unit time.Duration) {
+	at, err := s.DB.ExpireTime(cmd.Args[0])
+	switch {
+	case err != nil:
+		cmd.integer(-2)
+	case at == 0:
+		cmd.integer(-1)
+	default:
+		cmd.integer(int(at / unit.Milliseconds()))
 	}
-	cmd.write(strconv.FormatInt(exp, 10))
 }
 
+// TTL key
+//
+// Returns -1 if the key has no expiration time, and -2 if the key
+// does not exist.
 func (s *Server) ttl(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
+	s.ttlIn(cmd, time.Second)
+}
+
+// PTTL key
+func (s *Server) pTTL(cmd Command) {
+	s.ttlIn(cmd, time.Millisecond)
+}
+
+func (s *Server) ttlIn(cmd Command, unit time.Duration) {
 	ttl, err := s.DB.TTL(cmd.Args[0])
-	if err != nil {
-		cmd.error(err)
-		return
+	switch {
+	case err != nil:
+		cmd.integer(-2)
+	case ttl < 0:
+		cmd.integer(-1)
+	default:
+		// Round to the closest unit
+		cmd.integer(int((ttl + unit/2) / unit))
 	}
-	cmd.write(strconv.FormatFloat(ttl, 'f', 0, 64))
 }
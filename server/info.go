@@ -0,0 +1,215 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sections of INFO, in the order they are reported.
+var infoSections = []string{"server", "clients", "memory", "stats", "keyspace"}
+
+// Interval between two samples of the number of processed commands.
+const opsSampleInterval = 100 * time.Millisecond
+
+// opsMeter computes the instantaneous number of commands per second
+// as the average of the last samples.
+type opsMeter struct {
+	Mutex     *sync.Mutex
+	samples   [16]int64
+	index     int
+	lastCount int64
+	lastTime  time.Time
+}
+
+func newOpsMeter() *opsMeter {
+	return &opsMeter{Mutex: &sync.Mutex{}, lastTime: time.Now()}
+}
+
+// Record the total number of processed commands at a point in time.
+func (m *opsMeter) sample(count int64, now time.Time) {
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+
+	elapsed := now.Sub(m.lastTime)
+	// The counters may have been reset by CONFIG RESETSTAT
+	if elapsed > 0 && count >= m.lastCount {
+		m.samples[m.index] = (count - m.lastCount) * int64(time.Second) / int64(elapsed)
+		m.index = (m.index + 1) % len(m.samples)
+	}
+	m.lastCount, m.lastTime = count, now
+}
+
+func (m *opsMeter) perSecond() int64 {
+	m.Mutex.Lock()
+	defer m.Mutex.Unlock()
+
+	var sum int64
+	for _, sample := range m.samples {
+		sum += sample
+	}
+	return sum / int64(len(m.samples))
+}
+
+// Sample the number of processed commands until the server is
+// shut down.
+func (s *Server) trackOps() {
+	ticker := time.NewTicker(opsSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.quit:
+			return
+		case now := <-ticker.C:
+			s.ops.sample(s.totalCommands(), now)
+		}
+	}
+}
+
+// Returns the number of commands processed by every listener.
+func (s *Server) totalCommands() int64 {
+	var total int64
+	for _, listener := range s.Listeners {
+		total += listener.Commands.Load()
+	}
+	return total
+}
+
+// INFO [section [section ...]]
+//
+// Without arguments, or with "default", "all" or "everything",
+// every section is reported.
+func (s *Server) info(cmd Command) {
+	selected := make(map[string]bool)
+	for _, arg := range cmd.Args {
+		switch section := strings.ToLower(arg); section {
+		case "default", "all", "everything":
+			for _, name := range infoSections {
+				selected[name] = true
+			}
+		default:
+			selected[section] = true
+		}
+	}
+
+	var info strings.Builder
+	for _, section := range infoSections {
+		if len(cmd.Args) > 0 && !selected[section] {
+			continue
+		}
+		if info.Len() > 0 {
+			info.WriteString("\r\n")
+		}
+		fmt.Fprintf(&info, "# %s\r\n", strings.ToUpper(section[:1])+section[1:])
+		for _, field := range s.infoSection(section) {
+			fmt.Fprintf(&info, "%s:%v\r\n", field.name, field.value)
+		}
+	}
+	cmd.logError(cmd.Client.Writer.WriteVerbatim("txt", info.String()))
+}
+
+type infoField struct {
+	name  string
+	value any
+}
+
+// Returns the fields of a section of INFO.
+func (s *Server) infoSection(section string) []infoField {
+	switch section {
+	case "server":
+		uptime := time.Since(s.Started)
+		fields := []infoField{
+			{"tandb_version", Version},
+			{"go_version", runtime.Version()},
+			{"os", runtime.GOOS},
+			{"arch_bits", 32 << (^uint(0) >> 63)},
+			{"process_id", os.Getpid()},
+			{"goroutines", runtime.NumGoroutine()},
+			{"uptime_in_seconds", int64(uptime.Seconds())},
+			{"uptime_in_days", int64(uptime.Hours() / 24)},
+			{"config_file", s.Config.Path},
+		}
+		for i, listener := range s.Listeners {
+			fields = append(fields, infoField{
+				fmt.Sprintf("listener%d", i),
+				fmt.Sprintf("name=%s,addr=%s", listener.Config.String(), listener.Addr()),
+			})
+		}
+		return fields
+	case "clients":
+		s.Mutex.Lock()
+		connected := len(s.Clients)
+		s.Mutex.Unlock()
+		return []infoField{
+			{"connected_clients", connected},
+			{"maxclients", s.Config.Int("maxclients")},
+		}
+	case "memory":
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		return []infoField{
+			{"used_memory", stats.HeapAlloc},
+			{"used_memory_human", humanBytes(stats.HeapAlloc)},
+			{"used_memory_sys", stats.Sys},
+			{"used_memory_sys_human", humanBytes(stats.Sys)},
+			{"heap_objects", stats.HeapObjects},
+			{"total_allocated", stats.TotalAlloc},
+			{"gc_cycles", stats.NumGC},
+			{"gc_pause_total_ms", stats.PauseTotalNs / uint64(time.Millisecond)},
+		}
+	case "stats":
+		var accepted, rejected int64
+		for _, listener := range s.Listeners {
+			accepted += listener.Accepted.Load()
+			rejected += listener.Rejected.Load()
+		}
+		stats := s.DB.Stats
+		fields := []infoField{
+			{"total_connections_received", accepted},
+			{"rejected_connections", rejected},
+			{"total_commands_processed", s.totalCommands()},
+			{"instantaneous_ops_per_sec", s.ops.perSecond()},
+			{"keyspace_hits", stats.Hits.Load()},
+			{"keyspace_misses", stats.Misses.Load()},
+			{"expired_keys", stats.Expired.Load()},
+			{"evicted_keys", stats.Evicted.Load()},
+		}
+		for i, listener := range s.Listeners {
+			stats := listener.Stats()
+			fields = append(fields, infoField{
+				fmt.Sprintf("listener%d_stats", i),
+				fmt.Sprintf("accepted=%d,connected=%d,rejected=%d,commands=%d",
+					stats.Accepted, stats.Connected, stats.Rejected, stats.Commands),
+			})
+		}
+		return fields
+	case "keyspace":
+		keyspace := s.DB.Info()
+		// Only the database 0 exists, and empty databases are omitted
+		if keyspace.Keys() == 0 {
+			return nil
+		}
+		return []infoField{{"db0", fmt.Sprintf("keys=%d,expires=%d,strings=%d,sets=%d,zsets=%d",
+			keyspace.Keys(), keyspace.Expires, keyspace.Strings, keyspace.Sets, keyspace.ZSets)}}
+	}
+	return nil
+}
+
+// Format a number of bytes with a binary unit, such as 1.50M.
+func humanBytes(n uint64) string {
+	units := []string{"B", "K", "M", "G", "T"}
+	value := float64(n)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%dB", n)
+	}
+	return fmt.Sprintf("%.2f%s", value, units[unit])
+}
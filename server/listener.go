@@ -0,0 +1,105 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+)
+
+// ListenerConfig describes an address the server accepts
+// connections on.
+type ListenerConfig struct {
+	// Either "tcp" or "unix"
+	Network string
+	// Host and port for TCP, or the path of a Unix socket
+	Addr string
+	// Permissions of the Unix socket file, ignored for TCP. When
+	// zero the permissions are left to the process umask.
+	Perm os.FileMode
+	// Settings of TLS, which is disabled when nil
+	TLS *TLSConfig
+}
+
+func (c ListenerConfig) String() string {
+	scheme := c.Network
+	if c.TLS != nil {
+		scheme += "+tls"
+	}
+	return scheme + "://" + c.Addr
+}
+
+// Listener is a bound listener along with its statistics. Every
+// listener feeds its connections into the same command pipeline.
+type Listener struct {
+	net.Listener
+	Config ListenerConfig
+
+	// Total number of accepted connections
+	Accepted atomic.Int64
+	// Number of currently connected clients
+	Connected atomic.Int64
+	// Connections refused because the server was shutting down
+	// or the maximum number of clients was reached
+	Rejected atomic.Int64
+	// Number of commands run by clients of the listener
+	Commands atomic.Int64
+}
+
+// Statistics of a listener at a point in time.
+type ListenerStats struct {
+	Name      string
+	Addr      string
+	Accepted  int64
+	Connected int64
+	Rejected  int64
+	Commands  int64
+}
+
+var ErrUnknownNetwork = errors.New("listener network must be tcp or unix")
+
+// Bind a listener. A stale Unix socket file left behind by a
+// previous process is removed first.
+func listen(config ListenerConfig) (*Listener, error) {
+	if config.Network != "tcp" && config.Network != "unix" {
+		return nil, ErrUnknownNetwork
+	}
+	if config.Network == "unix" {
+		if info, err := os.Stat(config.Addr); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(config.Addr)
+		}
+	}
+
+	listener, err := net.Listen(config.Network, config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	if config.Network == "unix" && config.Perm != 0 {
+		if err := os.Chmod(config.Addr, config.Perm); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	if config.TLS != nil {
+		tlsConfig, err := config.TLS.Build()
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("%s: %w", config, err)
+		}
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	return &Listener{Listener: listener, Config: config}, nil
+}
+
+func (l *Listener) Stats() ListenerStats {
+	return ListenerStats{
+		Name:      l.Config.String(),
+		Addr:      l.Addr().String(),
+		Accepted:  l.Accepted.Load(),
+		Connected: l.Connected.Load(),
+		Rejected:  l.Rejected.Load(),
+		Commands:  l.Commands.Load(),
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// Log levels, from the most to the least verbose.
+const (
+	LogDebug = iota
+	LogVerbose
+	LogNotice
+	LogWarning
+)
+
+var logLevels = map[string]int32{
+	"debug":   LogDebug,
+	"verbose": LogVerbose,
+	"notice":  LogNotice,
+	"warning": LogWarning,
+}
+
+var logLevel atomic.Int32
+
+func init() {
+	logLevel.Store(LogNotice)
+}
+
+// Set the minimum level of logged messages by its name.
+func setLogLevel(name string) error {
+	level, ok := logLevels[name]
+	if !ok {
+		return ErrSyntax
+	}
+	logLevel.Store(level)
+	return nil
+}
+
+// Log a message if its level is enabled.
+func logf(level int32, format string, args ...any) {
+	if level >= logLevel.Load() {
+		log.Printf(format, args...)
+	}
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bind the HTTP listener serving metrics, if an address is configured.
+func (s *Server) listenMetrics() error {
+	if s.MetricsAddr == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", s.MetricsAddr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.serveMetrics)
+
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	s.metricsListener = listener
+	s.metrics = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return nil
+}
+
+// Serve HTTP requests for metrics until the server is shut down.
+func (s *Server) runMetrics() {
+	if s.metrics == nil {
+		return
+	}
+	err := s.metrics.Serve(s.metricsListener)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logf(LogWarning, "unable to serve metrics: %v", err)
+	}
+}
+
+// Handle a scrape of the metrics, in the Prometheus text format.
+func (s *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.WriteMetrics(w)
+}
+
+// metricsWriter writes metrics in the Prometheus text format.
+type metricsWriter struct {
+	w io.Writer
+}
+
+// Write the HELP and TYPE lines of a metric.
+func (m metricsWriter) header(name, kind, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// Write a sample. Labels are given as name and value pairs.
+func (m metricsWriter) sample(name string, value any, labels ...string) {
+	if len(labels) > 0 {
+		pairs := make([]string, 0, len(labels)/2)
+		for i := 0; i+1 < len(labels); i += 2 {
+			pairs = append(pairs, labels[i]+"="+strconv.Quote(labels[i+1]))
+		}
+		name += "{" + strings.Join(pairs, ",") + "}"
+	}
+	fmt.Fprintf(m.w, "%s %v\n", name, value)
+}
+
+// Write every metric of the server.
+func (s *Server) WriteMetrics(w io.Writer) {
+	m := metricsWriter{w: w}
+
+	names := make([]string, 0, len(s.commandStats))
+	for name, stats := range s.commandStats {
+		if stats.Calls.Load() > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	m.header("tandb_commands_total", "counter", "Number of calls of each command.")
+	for _, name := range names {
+		m.sample("tandb_commands_total", s.commandStats[name].Calls.Load(), "command", strings.ToLower(name))
+	}
+	m.header("tandb_command_duration_seconds", "histogram", "Latency of the calls of each command.")
+	for _, name := range names {
+		stats := s.commandStats[name]
+		command := strings.ToLower(name)
+		var cumulative int64
+		for i, bound := range latencyBuckets {
+			cumulative += stats.Buckets[i].Load()
+			m.sample("tandb_command_duration_seconds_bucket", cumulative,
+				"command", command, "le", strconv.FormatFloat(bound.Seconds(), 'g', -1, 64))
+		}
+		cumulative += stats.Buckets[len(latencyBuckets)].Load()
+		m.sample("tandb_command_duration_seconds_bucket", cumulative, "command", command, "le", "+Inf")
+		m.sample("tandb_command_duration_seconds_sum", time.Duration(stats.Duration.Load()).Seconds(), "command", command)
+		m.sample("tandb_command_duration_seconds_count", cumulative, "command", command)
+	}
+
+	errorCounts := s.errorStats.snapshot()
+	codes := make([]string, 0, len(errorCounts))
+	for code := range errorCounts {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	m.header("tandb_errors_total", "counter", "Number of error replies by error type.")
+	for _, code := range codes {
+		m.sample("tandb_errors_total", errorCounts[code], "error", code)
+	}
+
+	s.Mutex.Lock()
+	connected := len(s.Clients)
+	s.Mutex.Unlock()
+	m.header("tandb_connected_clients", "gauge", "Number of connected clients.")
+	m.sample("tandb_connected_clients", connected)
+
+	keyspace := s.DB.Info()
+	m.header("tandb_keys", "gauge", "Number of keys by data type.")
+	m.sample("tandb_keys", keyspace.Strings, "type", "string")
+	m.sample("tandb_keys", keyspace.Sets, "type", "set")
+	m.sample("tandb_keys", keyspace.ZSets, "type", "zset")
+	m.header("tandb_keys_with_expiry", "gauge", "Number of keys with an expiration time.")
+	m.sample("tandb_keys_with_expiry", keyspace.Expires)
+
+	stats := s.DB.Stats
+	m.header("tandb_expired_keys_total", "counter", "Number of keys removed because they expired.")
+	m.sample("tandb_expired_keys_total", stats.Expired.Load())
+	m.header("tandb_ttl_sweeps_total", "counter", "Number of cycles of the expired keys sweeper.")
+	m.sample("tandb_ttl_sweeps_total", stats.Sweeps.Load())
+	m.header("tandb_ttl_sweep_duration_seconds_total", "counter", "Time spent by the expired keys sweeper.")
+	m.sample("tandb_ttl_sweep_duration_seconds_total", time.Duration(stats.SweepTime.Load()).Seconds())
+	m.header("tandb_keyspace_hits_total", "counter", "Number of lookups of existing keys.")
+	m.sample("tandb_keyspace_hits_total", stats.Hits.Load())
+	m.header("tandb_keyspace_misses_total", "counter", "Number of lookups of missing keys.")
+	m.sample("tandb_keyspace_misses_total", stats.Misses.Load())
+
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	m.header("go_info", "gauge", "Version of the Go runtime.")
+	m.sample("go_info", 1, "version", runtime.Version())
+	m.header("go_goroutines", "gauge", "Number of goroutines.")
+	m.sample("go_goroutines", runtime.NumGoroutine())
+	m.header("go_memstats_heap_alloc_bytes", "gauge", "Bytes of allocated heap objects.")
+	m.sample("go_memstats_heap_alloc_bytes", memory.HeapAlloc)
+	m.header("go_memstats_heap_objects", "gauge", "Number of allocated heap objects.")
+	m.sample("go_memstats_heap_objects", memory.HeapObjects)
+	m.header("go_memstats_sys_bytes", "gauge", "Bytes of memory obtained from the system.")
+	m.sample("go_memstats_sys_bytes", memory.Sys)
+	m.header("go_memstats_alloc_bytes_total", "counter", "Total bytes allocated for heap objects.")
+	m.sample("go_memstats_alloc_bytes_total", memory.TotalAlloc)
+	m.header("go_gc_cycles_total", "counter", "Number of completed garbage collection cycles.")
+	m.sample("go_gc_cycles_total", memory.NumGC)
+	m.header("go_gc_pause_seconds_total", "counter", "Total time the program was paused by garbage collection.")
+	m.sample("go_gc_pause_seconds_total", time.Duration(memory.PauseTotalNs).Seconds())
+}
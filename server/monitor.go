@@ -0,0 +1,207 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Number of lines buffered for a monitor client. A monitor which
+// falls further behind is disconnected, so that slow monitors never
+// block the clients running commands.
+const MonitorBufferSize = 1024
+
+type monitor struct {
+	client *Client
+	lines  chan string
+	// Closed when the monitor stops
+	stop chan struct{}
+	once *sync.Once
+}
+
+func (m *monitor) close() {
+	m.once.Do(func() { close(m.stop) })
+}
+
+// MONITOR
+//
+// Turn the connection into a stream of the commands run by the
+// server. Commands sent afterwards by the client are ignored.
+func (s *Server) monitor(cmd Command) {
+	client := cmd.Client
+	if client.HasFlag(ClientMonitor) {
+		cmd.ok()
+		return
+	}
+
+	s.Mutex.Lock()
+	s.monitors[client.ID] = &monitor{
+		client: client,
+		lines:  make(chan string, MonitorBufferSize),
+		stop:   make(chan struct{}),
+		once:   &sync.Once{},
+	}
+	s.monitorCount.Add(1)
+	s.Mutex.Unlock()
+
+	client.SetFlag(ClientMonitor, true)
+	cmd.ok()
+}
+
+// Write the lines fed to a monitor client until it disconnects, falls
+// behind or the server shuts down.
+func (s *Server) streamMonitor(client *Client) {
+	s.Mutex.Lock()
+	m, ok := s.monitors[client.ID]
+	s.Mutex.Unlock()
+	// The monitor may have been dropped already
+	if !ok {
+		return
+	}
+	defer s.removeMonitor(m)
+
+	// Input is only read to notice when the client disconnects
+	go func() {
+		io.Copy(io.Discard, client.Conn)
+		m.close()
+	}()
+
+	writer := client.Writer
+	for {
+		select {
+		case line := <-m.lines:
+			if err := writer.WriteSimpleString(line); err != nil {
+				return
+			}
+			if len(m.lines) == 0 {
+				if err := writer.Flush(); err != nil {
+					return
+				}
+			}
+		case <-m.stop:
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *Server) removeMonitor(m *monitor) {
+	m.close()
+
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	if _, ok := s.monitors[m.client.ID]; ok {
+		delete(s.monitors, m.client.ID)
+		s.monitorCount.Add(-1)
+	}
+}
+
+// Send a command to every monitor. Monitors whose buffer is full
+// are dropped instead of waiting for them.
+func (s *Server) feedMonitors(cmd Command) {
+	if s.monitorCount.Load() == 0 {
+		return
+	}
+	line := monitorLine(cmd, time.Now())
+
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	for id, m := range s.monitors {
+		select {
+		case m.lines <- line:
+		default:
+			logf(LogVerbose, "dropping monitor client %d: output buffer is full", id)
+			delete(s.monitors, id)
+			s.monitorCount.Add(-1)
+			m.close()
+		}
+	}
+}
+
+// Format a command as a monitor line, such as:
+//
+//	1339518083.107412 [0 127.0.0.1:60866] "set" "key" "value"
+func monitorLine(cmd Command, now time.Time) string {
+	var line strings.Builder
+	fmt.Fprintf(&line, "%d.%06d [%d %s]", now.Unix(), now.Nanosecond()/1000, cmd.Client.DB, cmd.Client.Addr)
+	for _, arg := range redactArgs(cmd) {
+		line.WriteByte(' ')
+		line.WriteString(quoteArg(arg))
+	}
+	return line.String()
+}
+
+const redacted = "(redacted)"
+
+// Returns the command name and arguments with secrets, such as
+// passwords and ACL rules, replaced.
+func redactArgs(cmd Command) []string {
+	args := append([]string{strings.ToLower(cmd.Value)}, cmd.Args...)
+	switch strings.ToUpper(cmd.Value) {
+	case CMD_AUTH:
+		for i := 1; i < len(args); i++ {
+			args[i] = redacted
+		}
+	case CMD_HELLO:
+		for i := 2; i < len(args); i++ {
+			if strings.EqualFold(args[i], "AUTH") {
+				for j := i + 1; j < len(args) && j <= i+2; j++ {
+					args[j] = redacted
+				}
+				i += 2
+			}
+		}
+	case CMD_ACL:
+		if len(args) > 1 && strings.EqualFold(args[1], "SETUSER") {
+			for i := 3; i < len(args); i++ {
+				args[i] = redacted
+			}
+		}
+	case CMD_CONFIG:
+		if len(args) > 1 && strings.EqualFold(args[1], "SET") {
+			for i := 2; i+1 < len(args); i += 2 {
+				if strings.EqualFold(args[i], "requirepass") {
+					args[i+1] = redacted
+				}
+			}
+		}
+	}
+	return args
+}
+
+// Quote an argument, escaping quotes, backslashes and non printable
+// characters.
+func quoteArg(arg string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; c {
+		case '\\', '"':
+			quoted.WriteByte('\\')
+			quoted.WriteByte(c)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		case '\a':
+			quoted.WriteString(`\a`)
+		case '\b':
+			quoted.WriteString(`\b`)
+		default:
+			if c < 0x20 || c > 0x7e {
+				fmt.Fprintf(&quoted, `\x%02x`, c)
+			} else {
+				quoted.WriteByte(c)
+			}
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
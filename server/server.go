@@ -1,28 +1,84 @@
 package server
 
 import (
-	"bufio"
+	"context"
+	"crypto/tls"
 	"errors"
-	"log"
+	"io"
 	"net"
+	"net/http"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Devansh3712/tandb/acl"
+	"github.com/Devansh3712/tandb/config"
 	"github.com/Devansh3712/tandb/store"
 )
 
 const (
 	// Generic commands
-	CMD_GET         = "GET"
-	CMD_DEL         = "DEL"
-	CMD_SET         = "SET"
-	CMD_TTL         = "TTL"
-	CMD_KEYS        = "KEYS"
-	CMD_MGET        = "MGET"
-	CMD_SETEX       = "SETEX"
-	CMD_EXISTS      = "EXISTS"
-	CMD_EXPIRE      = "EXPIRE"
-	CMD_PERSIST     = "PERSIST"
-	CMD_EXPIRE_TIME = "EXPIRETIME"
+	CMD_GET          = "GET"
+	CMD_DEL          = "DEL"
+	CMD_RENAME       = "RENAME"
+	CMD_RENAMENX     = "RENAMENX"
+	CMD_COPY         = "COPY"
+	CMD_SET          = "SET"
+	CMD_SETNX        = "SETNX"
+	CMD_TTL          = "TTL"
+	CMD_PTTL         = "PTTL"
+	CMD_KEYS         = "KEYS"
+	CMD_MGET         = "MGET"
+	CMD_MSET         = "MSET"
+	CMD_MSETNX       = "MSETNX"
+	CMD_GETDEL       = "GETDEL"
+	CMD_GETSET       = "GETSET"
+	CMD_GETEX        = "GETEX"
+	CMD_SETEX        = "SETEX"
+	CMD_PSETEX       = "PSETEX"
+	CMD_EXISTS       = "EXISTS"
+	CMD_TYPE         = "TYPE"
+	CMD_EXPIRE       = "EXPIRE"
+	CMD_PEXPIRE      = "PEXPIRE"
+	CMD_EXPIRE_AT    = "EXPIREAT"
+	CMD_PEXPIRE_AT   = "PEXPIREAT"
+	CMD_PERSIST      = "PERSIST"
+	CMD_EXPIRE_TIME  = "EXPIRETIME"
+	CMD_PEXPIRE_TIME = "PEXPIRETIME"
+	// String commands
+	CMD_INCR        = "INCR"
+	CMD_DECR        = "DECR"
+	CMD_INCRBY      = "INCRBY"
+	CMD_DECRBY      = "DECRBY"
+	CMD_INCRBYFLOAT = "INCRBYFLOAT"
+	CMD_APPEND      = "APPEND"
+	CMD_STRLEN      = "STRLEN"
+	CMD_GETRANGE    = "GETRANGE"
+	CMD_SETRANGE    = "SETRANGE"
+	CMD_LCS         = "LCS"
+	// Bitmap commands
+	CMD_SETBIT      = "SETBIT"
+	CMD_GETBIT      = "GETBIT"
+	CMD_BITCOUNT    = "BITCOUNT"
+	CMD_BITPOS      = "BITPOS"
+	CMD_BITOP       = "BITOP"
+	CMD_BITFIELD    = "BITFIELD"
+	CMD_BITFIELD_RO = "BITFIELD_RO"
+	// Connection commands
+	CMD_AUTH   = "AUTH"
+	CMD_HELLO  = "HELLO"
+	CMD_CLIENT = "CLIENT"
+	// ACL commands
+	CMD_ACL = "ACL"
+	// Server commands
+	CMD_INFO     = "INFO"
+	CMD_CONFIG   = "CONFIG"
+	CMD_MONITOR  = "MONITOR"
+	CMD_SLOWLOG  = "SLOWLOG"
+	CMD_COMMAND  = "COMMAND"
+	CMD_SHUTDOWN = "SHUTDOWN"
 	// Set commands
 	CMD_SADD        = "SADD"
 	CMD_SCARD       = "SCARD"
@@ -39,118 +95,432 @@ const (
 	CMD_ZMEMBERS = "ZMEMBERS"
 )
 
-var ErrInvalidCmd = errors.New("invalid command")
-
 type Command struct {
-	Value string
-	Args  []string
-	Conn  net.Conn
+	Value  string
+	Args   []string
+	Client *Client
 }
 
 type Server struct {
-	Addr     string
-	Listener net.Listener
-	DB       store.Store
-	Commands chan Command
+	// Addresses to listen on, bound by Listen
+	ListenerConfigs []ListenerConfig
+	Listeners       []*Listener
+	DB              store.Store
+	Mutex           *sync.Mutex
+	Clients         map[int64]*Client
+	ACL             *acl.ACL
+	Config          *config.Config
+	Slowlog         *Slowlog
+	// Path of the ACL file used by ACL LOAD and ACL SAVE
+	ACLFile string
+	Started time.Time
+	// Address of the HTTP listener serving metrics, empty to disable it
+	MetricsAddr string
+
+	// Instantaneous number of commands per second
+	ops             *opsMeter
+	commandStats    map[string]*CommandStats
+	errorStats      *ErrorStats
+	metrics         *http.Server
+	metricsListener net.Listener
+	// Clients streaming the commands run by the server, guarded
+	// by the mutex. The count is read without the lock.
+	monitors     map[int64]*monitor
+	monitorCount atomic.Int32
+
+	// Tracks the goroutines serving clients
+	wg *sync.WaitGroup
+	// Closed when a shutdown begins
+	quit chan struct{}
+	// Closed when a shutdown has completed
+	done    chan struct{}
+	stopTTL context.CancelFunc
+}
+
+var (
+	ErrServerClosed  = errors.New("server closed")
+	ErrShuttingDown  = errors.New("server is shutting down")
+	ErrNotListening  = errors.New("server is not listening")
+	ErrAlreadyClosed = errors.New("server is already shutting down")
+	ErrMaxClients    = errors.New("max number of clients reached")
+)
+
+// Create a server listening on a TCP address. More listeners can be
+// added with AddListener, and an empty address creates a server
+// without any listener.
+func NewServer(addr string) *Server {
+	s := &Server{
+		DB:      store.NewStore(),
+		Mutex:   &sync.Mutex{},
+		Clients: make(map[int64]*Client),
+		ACL:     acl.NewACL(commandExists),
+		Config:  config.New(),
+		Slowlog: NewSlowlog(),
+		Started: time.Now(),
+		ops:     newOpsMeter(),
+
+		commandStats: newCommandStatsTable(),
+		errorStats:   newErrorStats(),
+		monitors:     make(map[int64]*monitor),
+		wg:           &sync.WaitGroup{},
+		quit:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	if addr != "" {
+		s.AddListener(ListenerConfig{Network: "tcp", Addr: addr})
+	}
+	// The default configuration is always valid
+	s.watchConfig()
+	return s
+}
+
+// Add an address to listen on. Must be called before Listen.
+func (s *Server) AddListener(config ListenerConfig) {
+	s.ListenerConfigs = append(s.ListenerConfigs, config)
 }
 
-func NewServer(addr string) Server {
-	return Server{
-		Addr: addr, Commands: make(chan Command), DB: store.NewStore(),
+// Load the ACL file if one is configured, then listen on the address
+// of the server and serve connections. Start blocks until the server
+// has been shut down, and then returns ErrServerClosed.
+func (s *Server) Start() error {
+	if s.ACLFile != "" {
+		if err := s.ACL.Load(s.ACLFile); err != nil {
+			return err
+		}
+	}
+	if err := s.Listen(); err != nil {
+		return err
 	}
+	return s.Serve()
 }
 
-func (s *Server) Start() {
-	listener, err := net.Listen("tcp", s.Addr)
-	if err != nil {
-		panic(err)
+// Bind every listener of the server without accepting connections
+// yet. If any listener fails, the ones already bound are closed.
+func (s *Server) Listen() error {
+	if len(s.ListenerConfigs) == 0 {
+		return ErrNotListening
+	}
+	var listeners []*Listener
+	for _, config := range s.ListenerConfigs {
+		listener, err := listen(config)
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, listener)
 	}
-	defer listener.Close()
-	s.Listener = listener
+	if err := s.listenMetrics(); err != nil {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+		return err
+	}
+
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
 
-	go s.DB.CheckTTL()
-	s.HandleConnections()
+	s.Listeners = listeners
+	return nil
 }
 
-func (s *Server) HandleConnections() {
+// Accept and serve connections on the bound listeners until the
+// server is shut down. Returns ErrServerClosed once the shutdown
+// has completed.
+func (s *Server) Serve() error {
+	if len(s.Listeners) == 0 {
+		return ErrNotListening
+	}
+	s.startSweeper()
+	go s.trackOps()
+	go s.runMetrics()
+	for _, listener := range s.Listeners {
+		go s.HandleConnections(listener)
+	}
+	<-s.done
+	return ErrServerClosed
+}
+
+// Start the background job removing expired keys, stopping the
+// previous one if it is running.
+func (s *Server) startSweeper() {
+	interval := time.Duration(s.Config.Int("ttl-sweep-interval")) * time.Millisecond
+	budget := interval * time.Duration(s.Config.Int("ttl-sweep-budget")) / 100
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	if s.stopTTL != nil {
+		s.stopTTL()
+	}
+	s.stopTTL = cancel
+	if s.shuttingDown() {
+		cancel()
+		return
+	}
+	go s.DB.CheckTTL(ctx, interval, budget)
+}
+
+// Returns the statistics of every listener.
+func (s *Server) ListenerStats() []ListenerStats {
+	stats := make([]ListenerStats, len(s.Listeners))
+	for i, listener := range s.Listeners {
+		stats[i] = listener.Stats()
+	}
+	return stats
+}
+
+func (s *Server) HandleConnections(listener *Listener) {
 	for {
-		conn, err := s.Listener.Accept()
+		conn, err := listener.Accept()
 		if err != nil {
-			log.Printf("unable to accept connection: %v", err)
+			if s.shuttingDown() {
+				return
+			}
+			logf(LogWarning, "unable to accept connection: %v", err)
 			continue
 		}
-		go s.ReadCommand(conn)
-		go s.HandleCommand()
+		listener.Accepted.Add(1)
+		client := NewClient(conn, listener)
+		client.Reader.MaxBulkLen = s.Config.Int("proto-max-bulk-len")
+		client.errorStats = s.errorStats
+		if err := s.addClient(client); err != nil {
+			listener.Rejected.Add(1)
+			client.Writer.WriteError("ERR " + err.Error())
+			client.Close()
+			continue
+		}
+		go s.HandleClient(client)
+	}
+}
+
+// Register a client, unless a shutdown has already begun or the
+// maximum number of clients is connected.
+func (s *Server) addClient(client *Client) error {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	if s.shuttingDown() {
+		return ErrShuttingDown
+	}
+	if len(s.Clients) >= s.Config.Int("maxclients") {
+		return ErrMaxClients
 	}
+	client.Authenticated = s.ACL.DefaultAuthenticated()
+	s.Clients[client.ID] = client
+	client.Listener.Connected.Add(1)
+	s.wg.Add(1)
+	return nil
+}
+
+func (s *Server) removeClient(client *Client) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	delete(s.Clients, client.ID)
+	client.Listener.Connected.Add(-1)
+	s.wg.Done()
 }
 
-func (s *Server) ReadCommand(conn net.Conn) {
-	defer conn.Close()
+// Returns the connected clients sorted by their ID.
+func (s *Server) ClientList() []*Client {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	clients := make([]*Client, 0, len(s.Clients))
+	for _, client := range s.Clients {
+		clients = append(clients, client)
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+	return clients
+}
+
+// Disconnect every client matching the filter and return the number
+// of clients killed. The current client is closed after its reply is
+// written, while other clients are closed immediately.
+func (s *Server) KillClients(current *Client, filter func(*Client) bool) int {
+	killed := 0
+	for _, client := range s.ClientList() {
+		if !filter(client) {
+			continue
+		}
+		if client == current {
+			client.SetFlag(ClientCloseAfterReply, true)
+		} else {
+			client.Conn.Close()
+		}
+		killed++
+	}
+	return killed
+}
+
+func (s *Server) shuttingDown() bool {
+	select {
+	case <-s.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// Serve a single connection. Commands of a client are executed one
+// at a time in the order they were sent, and the replies are buffered
+// until every pipelined command which has already been received is
+// handled. The next command is not read until the previous one has
+// been replied to, so a client which stops reading its replies blocks
+// on writes instead of growing the server's buffers.
+func (s *Server) HandleClient(client *Client) {
+	defer s.removeClient(client)
+	defer client.Close()
+
+	if conn, ok := client.Conn.(*tls.Conn); ok {
+		if err := s.handshake(client, conn); err != nil {
+			logf(LogVerbose, "unable to complete tls handshake with %s: %v", client.Addr, err)
+			return
+		}
+	}
+
 	for {
-		input, err := bufio.NewReader(conn).ReadString('\n')
+		cmd, err := client.ReadCommand()
 		if err != nil {
-			log.Printf("unable to read from connection: %v", err)
-			break
+			if s.shuttingDown() {
+				client.Writer.WriteError("ERR " + ErrShuttingDown.Error())
+			} else if err != io.EOF {
+				logf(LogVerbose, "unable to read from connection: %v", err)
+				client.Writer.WriteError("ERR " + err.Error())
+			}
+			return
+		}
+		// Pipelined commands which were not started before
+		// the shutdown are rejected
+		if s.shuttingDown() {
+			cmd.error(ErrShuttingDown)
+			return
+		}
+		client.touch(cmd.Value)
+		client.Listener.Commands.Add(1)
+		s.HandleCommand(cmd)
+		if client.HasFlag(ClientCloseAfterReply) {
+			return
+		}
+		if client.HasFlag(ClientMonitor) {
+			if err := client.Writer.Flush(); err == nil {
+				s.streamMonitor(client)
+			}
+			return
+		}
+
+		// Flush the replies once the pipeline has been drained
+		if client.Reader.Buffered() == 0 {
+			if err := client.Writer.Flush(); err != nil {
+				logf(LogVerbose, "unable to write to connection: %v", err)
+				return
+			}
 		}
-		cmd := strings.Trim(input, "\r\n")
-		args := strings.Split(cmd, " ")
-		s.Commands <- Command{
-			Value: args[0], Args: args[1:], Conn: conn,
+	}
+}
+
+// Time allowed for a client to complete the TLS handshake.
+const HandshakeTimeout = 10 * time.Second
+
+// Complete the TLS handshake of a client. If the client presented a
+// verified certificate and clients are authenticated by their common
+// name, the client is authenticated as the ACL user of that name.
+func (s *Server) handshake(client *Client, conn *tls.Conn) error {
+	conn.SetDeadline(time.Now().Add(HandshakeTimeout))
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	if !strings.EqualFold(client.Listener.Config.TLS.AuthClientsUser, "CN") {
+		return nil
+	}
+	if name, ok := peerCommonName(conn); ok {
+		if user, ok := s.ACL.GetUser(name); ok && user.Enabled {
+			client.SetUser(name)
 		}
 	}
+	return nil
 }
 
-func (s *Server) HandleCommand() {
-	for cmd := range s.Commands {
-		switch cmd.Value {
-		case CMD_GET:
-			s.get(cmd)
-		case CMD_DEL:
-			s.del(cmd)
-		case CMD_SET:
-			s.set(cmd)
-		case CMD_TTL:
-			s.ttl(cmd)
-		case CMD_KEYS:
-			s.keys(cmd)
-		case CMD_MGET:
-			s.mGet(cmd)
-		case CMD_SETEX:
-			s.setEx(cmd)
-		case CMD_EXISTS:
-			s.exists(cmd)
-		case CMD_EXPIRE:
-			s.expire(cmd)
-		case CMD_PERSIST:
-			s.persist(cmd)
-		case CMD_EXPIRE_TIME:
-			s.expireTime(cmd)
-		case CMD_SADD:
-			s.sAdd(cmd)
-		case CMD_SCARD:
-			s.sCard(cmd)
-		case CMD_SDIFF:
-			s.sDiff(cmd)
-		case CMD_SINTER:
-			s.sInter(cmd)
-		case CMD_SUNION:
-			s.sUnion(cmd)
-		case CMD_SMEMBERS:
-			s.sMembers(cmd)
-		case CMD_SISMEMBER:
-			s.sIsMember(cmd)
-		case CMD_SDIFFSTORE:
-			s.sDiffStore(cmd)
-		case CMD_SINTERSTORE:
-			s.sInterStore(cmd)
-		case CMD_ZADD:
-			s.zAdd(cmd)
-		case CMD_ZCARD:
-			s.zCard(cmd)
-		case CMD_ZMEMBERS:
-			s.zMembers(cmd)
-		default:
-			cmd.error(ErrInvalidCmd)
+// Gracefully stop the server. The listener is closed, idle clients
+// are disconnected with an error reply, and clients running a command
+// are allowed to finish it and receive its reply. If the context
+// expires before every client has been disconnected, the remaining
+// connections are closed and the error of the context is returned.
+// Background jobs of the store are stopped last.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Mutex.Lock()
+	if s.shuttingDown() {
+		s.Mutex.Unlock()
+		return ErrAlreadyClosed
+	}
+	close(s.quit)
+	for _, listener := range s.Listeners {
+		listener.Close()
+	}
+	if s.metrics != nil {
+		s.metrics.Close()
+	}
+	// Interrupt clients blocked on reading their next command
+	for _, client := range s.Clients {
+		client.Conn.SetReadDeadline(time.Now())
+	}
+	s.Mutex.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+		s.Mutex.Lock()
+		for _, client := range s.Clients {
+			client.Conn.Close()
 		}
+		s.Mutex.Unlock()
+		<-drained
+	}
+
+	s.Mutex.Lock()
+	if s.stopTTL != nil {
+		s.stopTTL()
+	}
+	s.Mutex.Unlock()
+	close(s.done)
+	return err
+}
+
+// Look up the command in the command table, validate its
+// arity and run its handler. Command names are case-insensitive.
+func (s *Server) HandleCommand(cmd Command) {
+	spec, ok := LookupCommand(cmd.Value)
+	if !ok {
+		cmd.error(unknownCommand(cmd))
+		return
+	}
+	if !spec.checkArity(len(cmd.Args) + 1) {
+		cmd.error(wrongArity(spec.Name))
+		return
+	}
+	if err := s.checkPermission(cmd, spec); err != nil {
+		cmd.error(err)
+		return
 	}
+	s.feedMonitors(cmd)
+	start := time.Now()
+	spec.Handler(s, cmd)
+	duration := time.Since(start)
+	s.commandStats[spec.Name].observe(duration)
+	s.Slowlog.Record(cmd, start, duration)
 }
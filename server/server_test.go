@@ -0,0 +1,844 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startTestServer(t *testing.T) *Server {
+	t.Helper()
+	server := NewServer("127.0.0.1:0")
+	if err := server.Listen(); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	go server.Serve()
+	return server
+}
+
+func dialTestServer(t *testing.T, server *Server) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", server.Listeners[0].Addr().String())
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return conn, bufio.NewReader(conn)
+}
+
+func TestPipeline(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	// The commands are all sent before the first reply is read, and
+	// the replies come back in the same order
+	conn.Write([]byte("SET key value\r\n*2\r\n$3\r\nGET\r\n$3\r\nkey\r\nGET missing\r\n"))
+	want := []string{"+OK\r\n", "$5\r\n", "value\r\n", "$-1\r\n"}
+	for _, line := range want {
+		got, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("got %s, wanted nil", err.Error())
+		}
+		if got != line {
+			t.Errorf("got %q, wanted %q", got, line)
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	server := NewServer("127.0.0.1:0")
+	if err := server.Listen(); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	result := make(chan error)
+	go func() { result <- server.Serve() }()
+
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+	conn.Write([]byte("SHUTDOWN NOSAVE\r\n"))
+
+	got, _ := reader.ReadString('\n')
+	want := "-ERR server is shutting down\r\n"
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+	if err := <-result; err != ErrServerClosed {
+		t.Errorf("got %v, wanted %v", err, ErrServerClosed)
+	}
+	if _, err := net.Dial("tcp", server.Listeners[0].Addr().String()); err == nil {
+		t.Errorf("got nil, wanted connection refused")
+	}
+}
+
+func TestMultipleListeners(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "tandb.sock")
+	server := NewServer("127.0.0.1:0")
+	server.AddListener(ListenerConfig{Network: "unix", Addr: socket, Perm: 0600})
+	if err := server.Listen(); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	go server.Serve()
+	defer server.Shutdown(context.Background())
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	if got := info.Mode().Perm(); got != 0600 {
+		t.Errorf("got %o, wanted %o", got, 0600)
+	}
+
+	for i, listener := range server.Listeners {
+		conn, err := net.Dial(listener.Addr().Network(), listener.Addr().String())
+		if err != nil {
+			t.Fatalf("got %s, wanted nil", err.Error())
+		}
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		fmt.Fprintf(conn, "SET key%d value\r\n", i)
+		got, _ := bufio.NewReader(conn).ReadString('\n')
+		if got != "+OK\r\n" {
+			t.Errorf("got %q, wanted %q", got, "+OK\r\n")
+		}
+		conn.Close()
+	}
+
+	for _, stats := range server.ListenerStats() {
+		if stats.Accepted != 1 || stats.Commands != 1 {
+			t.Errorf("%s: got %d accepted and %d commands, wanted 1 and 1",
+				stats.Name, stats.Accepted, stats.Commands)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	server := NewServer("127.0.0.1:0")
+	server.MetricsAddr = "127.0.0.1:0"
+	if err := server.Listen(); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	go server.Serve()
+	defer server.Shutdown(context.Background())
+
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+	conn.Write([]byte("GET key\r\nUNKNOWN\r\n"))
+	reader.ReadString('\n')
+	reader.ReadString('\n')
+
+	response, err := http.Get("http://" + server.metricsListener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	defer response.Body.Close()
+	body, _ := io.ReadAll(response.Body)
+
+	want := []string{
+		`tandb_commands_total{command="get"} 1`,
+		`tandb_command_duration_seconds_count{command="get"} 1`,
+		`tandb_errors_total{error="ERR"} 1`,
+		`tandb_connected_clients 1`,
+		`tandb_keyspace_misses_total 1`,
+	}
+	for _, line := range want {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Errorf("got no %q, wanted it in the metrics", line)
+		}
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	monitorConn, monitorReader := dialTestServer(t, server)
+	defer monitorConn.Close()
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	monitorConn.Write([]byte("MONITOR\r\n"))
+	if got, _ := monitorReader.ReadString('\n'); got != "+OK\r\n" {
+		t.Fatalf("got %q, wanted %q", got, "+OK\r\n")
+	}
+	conn.Write([]byte("AUTH secret\r\nGET \"a\\nb\"\r\n"))
+	reader.ReadString('\n')
+
+	want := []string{`"auth" "(redacted)"`, `"get" "a\nb"`}
+	for _, suffix := range want {
+		got, _ := monitorReader.ReadString('\n')
+		if !strings.HasSuffix(got, "] "+suffix+"\r\n") {
+			t.Errorf("got %q, wanted a line ending with %q", got, suffix)
+		}
+	}
+
+	// A monitor which falls behind is dropped
+	slow := &monitor{client: &Client{ID: -1}, lines: make(chan string, 1), stop: make(chan struct{}), once: &sync.Once{}}
+	server.Mutex.Lock()
+	server.monitors[slow.client.ID] = slow
+	server.monitorCount.Add(1)
+	server.Mutex.Unlock()
+	conn.Write([]byte("GET a\r\nGET b\r\n"))
+	reader.ReadString('\n')
+	reader.ReadString('\n')
+	select {
+	case <-slow.stop:
+	default:
+		t.Errorf("got a running monitor, wanted it to be dropped")
+	}
+}
+
+// Send an inline command and return the first line of its reply.
+func sendTestCommand(t *testing.T, conn net.Conn, reader *bufio.Reader, command string) string {
+	t.Helper()
+	if _, err := conn.Write([]byte(command + "\r\n")); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	reply, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	return reply
+}
+
+// Read a whole reply, whatever its type, and return its raw text.
+func readTestReply(t *testing.T, reader *bufio.Reader) string {
+	t.Helper()
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	reply := line
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	switch line[0] {
+	case '$', '!', '=':
+		if n >= 0 {
+			data := make([]byte, n+2)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				t.Fatalf("got %s, wanted nil", err.Error())
+			}
+			reply += string(data)
+		}
+	case '*', '~', '>', '%', '|':
+		if line[0] == '%' || line[0] == '|' {
+			n *= 2
+		}
+		for i := 0; i < n; i++ {
+			reply += readTestReply(t, reader)
+		}
+	}
+	return reply
+}
+
+// Send a command and return its whole reply.
+func sendTestRequest(t *testing.T, conn net.Conn, reader *bufio.Reader, command string) string {
+	t.Helper()
+	if _, err := conn.Write([]byte(command + "\r\n")); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	return readTestReply(t, reader)
+}
+
+func TestRESP3Replies(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	if got := sendTestRequest(t, conn, reader, "HELLO 3"); !strings.HasPrefix(got, "%") {
+		t.Fatalf("got %q, wanted a map", got)
+	}
+	// Only the replies which Redis sends as booleans use them
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"SET key value", "+OK\r\n"},
+		{"EXISTS key", "#t\r\n"},
+		{"EXPIRE key 100", ":1\r\n"},
+		{"PEXPIREAT key 4102444800000", ":1\r\n"},
+		{"PERSIST key", ":1\r\n"},
+		{"PERSIST key", ":0\r\n"},
+		{"SETNX key value", ":0\r\n"},
+		{"MSETNX a 1", ":1\r\n"},
+		{"RENAMENX a key", ":0\r\n"},
+		{"COPY a b", ":1\r\n"},
+		{"SADD set a", ":1\r\n"},
+		{"SISMEMBER set a", "#t\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestRequest(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"SET key a", "+OK\r\n"},
+		{"SET key b", "+OK\r\n"},
+		{"SET key c NX", "$-1\r\n"},
+		{"SET missing c XX", "$-1\r\n"},
+		{"SET key c XX PXAT 4102444800000", "+OK\r\n"},
+		{"SET key d KEEPTTL", "+OK\r\n"},
+		{"EXPIRETIME key", ":4102444800\r\n"},
+		{"SET key e PXAT 1", "+OK\r\n"},
+		{"EXISTS key", ":0\r\n"},
+		{"SET key f EX 0", "-ERR invalid expire time in 'set' command\r\n"},
+		{"SET key f EX 10 PX 10", "-ERR syntax error\r\n"},
+		{"SET key f NX XX", "-ERR syntax error\r\n"},
+		{"SETNX key g", ":1\r\n"},
+		{"SETNX key h", ":0\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestCommand(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+
+	// GET returns the previous value, even if the key is not set
+	if got := sendTestCommand(t, conn, reader, "SET key i NX GET"); got != "$1\r\n" {
+		t.Fatalf("got %q, wanted %q", got, "$1\r\n")
+	}
+	if got, _ := reader.ReadString('\n'); got != "g\r\n" {
+		t.Errorf("got %q, wanted %q", got, "g\r\n")
+	}
+}
+
+func TestMSet(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"MSET a 1 b 2 a 3", "+OK\r\n"},
+		{"MGET a b", "*2\r\n$1\r\n3\r\n$1\r\n2\r\n"},
+		{"MSET a 1 b", "-ERR wrong number of arguments for 'mset' command\r\n"},
+		// MSET overwrites values of any type and removes their TTL
+		{"SADD set x", ":1\r\n"},
+		{"SET ttl v EX 100", "+OK\r\n"},
+		{"MSET set v ttl w", "+OK\r\n"},
+		{"TYPE set", "+string\r\n"},
+		{"TTL ttl", ":-1\r\n"},
+		// MSETNX sets nothing if any key exists, whatever its type
+		{"MSETNX b 4 c 5", ":0\r\n"},
+		{"EXISTS c", ":0\r\n"},
+		{"SADD other x", ":1\r\n"},
+		{"MSETNX c 5 other 6", ":0\r\n"},
+		{"EXISTS c", ":0\r\n"},
+		{"MSETNX c 5 d 6 c 7", ":1\r\n"},
+		{"MGET c d", "*2\r\n$1\r\n7\r\n$1\r\n6\r\n"},
+		{"MSETNX e", "-ERR wrong number of arguments for 'msetnx' command\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestRequest(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+}
+
+func TestExpire(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"SET key value", "+OK\r\n"},
+		{"TTL key", ":-1\r\n"},
+		{"PEXPIRETIME key", ":-1\r\n"},
+		{"TTL missing", ":-2\r\n"},
+		{"EXPIRE key 100 XX", ":0\r\n"},
+		{"EXPIRE key 100 GT", ":0\r\n"},
+		{"EXPIREAT key 4102444800 LT", ":1\r\n"},
+		{"PEXPIRETIME key", ":4102444800000\r\n"},
+		{"PEXPIREAT key 4102444800001 LT", ":0\r\n"},
+		{"EXPIRE key 100 NX", ":0\r\n"},
+		{"EXPIRE key 100 NX GT", "-ERR NX and XX, GT or LT options at the same time are not compatible\r\n"},
+		{"PERSIST key", ":1\r\n"},
+		{"PERSIST key", ":0\r\n"},
+		{"PSETEX key 100000 value", "+OK\r\n"},
+		{"TTL key", ":100\r\n"},
+		{"EXPIRE key -1", ":1\r\n"},
+		{"EXISTS key", ":0\r\n"},
+		{"SETEX key 0 value", "-ERR invalid expire time in 'setex' command\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestCommand(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+}
+
+func TestGetAndModify(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"SET key value", "+OK\r\n"},
+		{"GETDEL key", "$5\r\nvalue\r\n"},
+		{"EXISTS key", ":0\r\n"},
+		{"GETDEL key", "$-1\r\n"},
+		// GETSET stores a persistent value
+		{"GETSET key a", "$-1\r\n"},
+		{"SET key b EX 100", "+OK\r\n"},
+		{"GETSET key c", "$1\r\nb\r\n"},
+		{"TTL key", ":-1\r\n"},
+		// GETEX without options keeps the TTL
+		{"GETEX key EX 100", "$1\r\nc\r\n"},
+		{"GETEX key", "$1\r\nc\r\n"},
+		{"TTL key", ":100\r\n"},
+		{"GETEX key PERSIST", "$1\r\nc\r\n"},
+		{"TTL key", ":-1\r\n"},
+		{"GETEX key PXAT 1", "$1\r\nc\r\n"},
+		{"EXISTS key", ":0\r\n"},
+		{"GETEX key PERSIST", "$-1\r\n"},
+		{"GETEX key EX 0", "-ERR invalid expire time in 'getex' command\r\n"},
+		{"GETEX key PERSIST EX 10", "-ERR syntax error\r\n"},
+		// Keys of other types are left untouched
+		{"SADD set x", ":1\r\n"},
+		{"GETDEL set", "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{"GETSET set v", "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{"GETEX set PERSIST", "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{"TYPE set", "+set\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestRequest(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+}
+
+func TestRenameAndCopy(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	wrongType := "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"SET a 1", "+OK\r\n"},
+		{"RENAME a b", "+OK\r\n"},
+		{"EXISTS a", ":0\r\n"},
+		{"GET b", "$1\r\n1\r\n"},
+		{"RENAME a b", "-ERR no such key\r\n"},
+		{"RENAMENX a b", "-ERR no such key\r\n"},
+		{"COPY a b", ":0\r\n"},
+		// The source and the destination are the same key
+		{"RENAME b b", "+OK\r\n"},
+		{"RENAMENX b b", ":0\r\n"},
+		{"COPY b b", "-ERR source and destination objects are the same\r\n"},
+		{"GET b", "$1\r\n1\r\n"},
+		// Values of any type are moved, replacing the destination
+		{"SADD set x", ":1\r\n"},
+		{"RENAME set b", "+OK\r\n"},
+		{"TYPE b", "+set\r\n"},
+		{"GET b", wrongType},
+		{"SMEMBERS b", "*1\r\n$1\r\nx\r\n"},
+		{"SET c 1", "+OK\r\n"},
+		{"RENAMENX b c", ":0\r\n"},
+		{"TYPE c", "+string\r\n"},
+		{"ZADD zset m", ":1\r\n"},
+		{"COPY zset c", ":0\r\n"},
+		{"COPY zset c DB 0 REPLACE", ":1\r\n"},
+		{"TYPE c", "+zset\r\n"},
+		{"INCR c", wrongType},
+		// Copies are independent of their source
+		{"ZADD zset n", ":1\r\n"},
+		{"ZCARD c", ":1\r\n"},
+		{"COPY zset c DB 1", "-ERR DB index is out of range\r\n"},
+		// The TTL of the destination is replaced by the TTL of the source
+		{"SET ttl v EX 100", "+OK\r\n"},
+		{"SET persistent w", "+OK\r\n"},
+		{"COPY persistent ttl REPLACE", ":1\r\n"},
+		{"TTL ttl", ":-1\r\n"},
+		{"SET ttl v EX 100", "+OK\r\n"},
+		{"RENAME persistent ttl", "+OK\r\n"},
+		{"TTL ttl", ":-1\r\n"},
+		{"SET source v EX 200", "+OK\r\n"},
+		{"COPY source copy", ":1\r\n"},
+		{"TTL copy", ":200\r\n"},
+		{"RENAME source ttl", "+OK\r\n"},
+		{"TTL ttl", ":200\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestRequest(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+}
+
+func TestLCS(t *testing.T) {
+	result, matches := lcs([]byte("ohmytext"), []byte("mynewtext"))
+	if string(result) != "mytext" {
+		t.Errorf("got %q, wanted %q", result, "mytext")
+	}
+	want := []lcsMatch{{a: [2]int{4, 7}, b: [2]int{5, 8}}, {a: [2]int{2, 3}, b: [2]int{0, 1}}}
+	if fmt.Sprint(matches) != fmt.Sprint(want) {
+		t.Errorf("got %v, wanted %v", matches, want)
+	}
+
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"SET key1 ohmytext", "+OK\r\n"},
+		{"APPEND key2 mynew", ":5\r\n"},
+		{"APPEND key2 text", ":9\r\n"},
+		{"STRLEN key2", ":9\r\n"},
+		{"LCS key1 key2 LEN", ":6\r\n"},
+		{"LCS key1 missing LEN", ":0\r\n"},
+		{"LCS key1 key2 LEN IDX", "-ERR If you want both the length and indexes, please just use IDX.\r\n"},
+		{"SETRANGE key1 -1 a", "-ERR offset is out of range\r\n"},
+		{"SETRANGE key1 536870912 a", "-ERR string exceeds maximum allowed size (proto-max-bulk-len)\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestCommand(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+}
+
+func TestTypes(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"SADD key a b", ":2\r\n"},
+		{"TYPE key", "+set\r\n"},
+		{"TYPE missing", "+none\r\n"},
+		{"ZADD key a", "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{"INCR key", "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{"GET key", "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{"SET key a GET", "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"},
+		{"EXPIRE key 100", ":1\r\n"},
+		{"TTL key", ":100\r\n"},
+		{"RENAME key zset", "+OK\r\n"},
+		{"EXISTS key", ":0\r\n"},
+		{"SCARD zset", ":2\r\n"},
+		{"SET zset a", "+OK\r\n"},
+		{"TYPE zset", "+string\r\n"},
+		{"TTL zset", ":-1\r\n"},
+		{"ZADD other a", ":1\r\n"},
+		{"DEL other zset", ":2\r\n"},
+		{"TYPE other", "+none\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestCommand(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	sendTestRequest(t, conn, reader, "MSET user:1 a user:2 b session:1 c")
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"KEYS session:*", "*1\r\n$9\r\nsession:1\r\n"},
+		{"KEYS user:[2-9]", "*1\r\n$6\r\nuser:2\r\n"},
+		{"KEYS zzz", "*0\r\n"},
+		{"KEYS", "-ERR wrong number of arguments for 'keys' command\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestRequest(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+	if got := sendTestRequest(t, conn, reader, "KEYS *"); !strings.HasPrefix(got, "*3\r\n") {
+		t.Errorf("got %q, wanted 3 keys", got)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"COMMAND COUNT", fmt.Sprintf(":%d\r\n", len(commandTable))},
+		{"COMMAND COUNT extra", "-ERR wrong number of arguments for 'command|count' command\r\n"},
+		{"COMMAND INFO get nope", "*2\r\n" +
+			"*10\r\n$3\r\nget\r\n:2\r\n*2\r\n+readonly\r\n+fast\r\n:1\r\n:1\r\n:1\r\n" +
+			"*3\r\n+@string\r\n+@read\r\n+@fast\r\n*0\r\n*0\r\n*0\r\n$-1\r\n"},
+		{"COMMAND DOCS get", "*2\r\n$3\r\nget\r\n*4\r\n$7\r\nsummary\r\n" +
+			"$34\r\nReturns the string value of a key.\r\n$5\r\ngroup\r\n$6\r\nstring\r\n"},
+		{"COMMAND DOCS nope", "*0\r\n"},
+		{"COMMAND GETKEYS MSET a 1 b 2", "*2\r\n$1\r\na\r\n$1\r\nb\r\n"},
+		{"COMMAND GETKEYS BITOP AND d a", "*2\r\n$1\r\nd\r\n$1\r\na\r\n"},
+		{"COMMAND GETKEYS KEYS *", "-ERR The command has no key arguments\r\n"},
+		{"COMMAND GETKEYS GET", "-ERR Invalid number of arguments specified for command\r\n"},
+		{"COMMAND GETKEYS nope", "-ERR Invalid command specified\r\n"},
+		{"COMMAND nope", "-ERR unknown subcommand 'nope' for 'command' command\r\n"},
+		// Arity is checked from the spec before the handler runs
+		{"GET", "-ERR wrong number of arguments for 'get' command\r\n"},
+		{"GET a b", "-ERR wrong number of arguments for 'get' command\r\n"},
+		{"SET a", "-ERR wrong number of arguments for 'set' command\r\n"},
+		{"nope a", "-ERR unknown command 'nope', with args beginning with: 'a'\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestRequest(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+	other, otherReader := dialTestServer(t, server)
+	defer other.Close()
+
+	id := sendTestRequest(t, conn, reader, "CLIENT ID")
+	otherID := sendTestRequest(t, other, otherReader, "CLIENT ID")
+	if id == otherID || !strings.HasPrefix(id, ":") || !strings.HasPrefix(otherID, ":") {
+		t.Fatalf("got %q and %q, wanted distinct ids", id, otherID)
+	}
+	otherID = strings.TrimSpace(otherID[1:])
+	otherAddr := other.LocalAddr().String()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"CLIENT ID extra", "-ERR wrong number of arguments for 'client|id' command\r\n"},
+		{"CLIENT GETNAME", "$-1\r\n"},
+		{`CLIENT SETNAME "bad name"`, "-ERR Client names cannot contain spaces, newlines or special characters.\r\n"},
+		{"CLIENT SETNAME tester", "+OK\r\n"},
+		{"CLIENT GETNAME", "$6\r\ntester\r\n"},
+		{"CLIENT LIST TYPE nope", "-ERR Unknown client type 'nope'\r\n"},
+		{"CLIENT LIST ID 0", "-ERR Invalid client ID\r\n"},
+		{"CLIENT LIST TYPE pubsub", "$0\r\n\r\n"},
+		{"CLIENT KILL ID 0", "-ERR client-id should be greater than 0\r\n"},
+		{"CLIENT KILL ID 1 SKIPME maybe", "-ERR syntax error\r\n"},
+		{"CLIENT KILL ID 1 USER", "-ERR syntax error\r\n"},
+		{"CLIENT KILL 127.0.0.1:1", "-ERR No such client\r\n"},
+		{"CLIENT KILL ADDR 127.0.0.1:1", ":0\r\n"},
+		{"CLIENT NOPE", "-ERR unknown subcommand 'nope' for 'client' command\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestRequest(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+
+	info := sendTestRequest(t, conn, reader, "CLIENT INFO")
+	if !strings.Contains(info, "id="+strings.TrimSpace(id[1:])+" ") || !strings.Contains(info, "name=tester ") {
+		t.Errorf("got %q, wanted the id and name of the client", info)
+	}
+	list := sendTestRequest(t, conn, reader, "CLIENT LIST")
+	if !strings.Contains(list, "name=tester ") || !strings.Contains(list, "addr="+otherAddr+" ") {
+		t.Errorf("got %q, wanted both clients", list)
+	}
+	list = sendTestRequest(t, conn, reader, "CLIENT LIST ID "+otherID)
+	if strings.Contains(list, "name=tester ") || !strings.Contains(list, "id="+otherID+" ") {
+		t.Errorf("got %q, wanted only client %s", list, otherID)
+	}
+
+	// Filters must all match, and the calling client is skipped
+	if got := sendTestRequest(t, conn, reader, "CLIENT KILL ID "+otherID+" ADDR 127.0.0.1:1"); got != ":0\r\n" {
+		t.Errorf("got %q, wanted :0", got)
+	}
+	if got := sendTestRequest(t, conn, reader, "CLIENT KILL USER default"); got != ":1\r\n" {
+		t.Errorf("got %q, wanted :1", got)
+	}
+	if _, err := otherReader.ReadByte(); err == nil {
+		t.Errorf("got nil, wanted the killed connection to be closed")
+	}
+	if got := sendTestRequest(t, conn, reader, "CLIENT GETNAME"); got != "$6\r\ntester\r\n" {
+		t.Errorf("got %q, wanted the calling client to survive", got)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	admin, adminReader := dialTestServer(t, server)
+	defer admin.Close()
+
+	for _, command := range []string{
+		"CONFIG SET requirepass secret",
+		"ACL SETUSER reader on >pw ~cache:* +@read",
+	} {
+		if got := sendTestRequest(t, admin, adminReader, command); got != "+OK\r\n" {
+			t.Fatalf("%s: got %q, wanted +OK", command, got)
+		}
+	}
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"GET cache:a", "-NOAUTH Authentication required.\r\n"},
+		{"HELLO 3", "-" + ErrHelloNoAuth.Error() + "\r\n"},
+		{"HELLO 3 SETNAME tester", "-" + ErrHelloNoAuth.Error() + "\r\n"},
+		{"HELLO 3 AUTH default wrong", "-WRONGPASS invalid username-password pair or user is disabled.\r\n"},
+		{"AUTH reader pw", "+OK\r\n"},
+		{"GET cache:a", "$-1\r\n"},
+		{"SET cache:a 1", "-NOPERM User has no permissions to run this command\r\n"},
+		{"GET other", "-NOPERM No permissions to access a key\r\n"},
+	}
+	for _, test := range tests {
+		if got := sendTestRequest(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+
+	// HELLO with AUTH authenticates an unauthenticated client
+	other, otherReader := dialTestServer(t, server)
+	defer other.Close()
+	if got := sendTestRequest(t, other, otherReader, "HELLO 2 AUTH default secret"); !strings.HasPrefix(got, "*14\r\n") {
+		t.Errorf("got %q, wanted the HELLO reply", got)
+	}
+	if got := sendTestRequest(t, other, otherReader, "SET a 1"); got != "+OK\r\n" {
+		t.Errorf("got %q, wanted +OK", got)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	// Returns the headers of the sections of an INFO reply
+	sections := func(command string) []string {
+		reply := sendTestRequest(t, conn, reader, command)
+		var headers []string
+		for _, line := range strings.Split(reply, "\r\n") {
+			if strings.HasPrefix(line, "# ") {
+				headers = append(headers, line[2:])
+			}
+		}
+		return headers
+	}
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"INFO", []string{"Server", "Clients", "Memory", "Stats", "Keyspace"}},
+		{"INFO everything", []string{"Server", "Clients", "Memory", "Stats", "Keyspace"}},
+		{"INFO server", []string{"Server"}},
+		{"INFO KEYSPACE Server", []string{"Server", "Keyspace"}},
+		{"INFO nope", nil},
+	}
+	for _, test := range tests {
+		if got := sections(test.command); !slices.Equal(got, test.want) {
+			t.Errorf("%s: got %v, wanted %v", test.command, got, test.want)
+		}
+	}
+
+	if got := sendTestRequest(t, conn, reader, "INFO server"); !strings.Contains(got, "tandb_version:"+Version+"\r\n") {
+		t.Errorf("got %q, wanted the version", got)
+	}
+	if got := sendTestRequest(t, conn, reader, "INFO keyspace"); got != "$12\r\n# Keyspace\r\n\r\n" {
+		t.Errorf("got %q, wanted an empty keyspace", got)
+	}
+	sendTestRequest(t, conn, reader, "SET a 1")
+	sendTestRequest(t, conn, reader, "SADD b 1")
+	sendTestRequest(t, conn, reader, "EXPIRE a 100")
+	want := "# Keyspace\r\ndb0:keys=2,expires=1,strings=1,sets=1,zsets=0\r\n"
+	if got := sendTestRequest(t, conn, reader, "INFO keyspace"); !strings.HasSuffix(got, want+"\r\n") {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
+
+func TestClientPermissions(t *testing.T) {
+	server := startTestServer(t)
+	defer server.Shutdown(context.Background())
+	admin, adminReader := dialTestServer(t, server)
+	defer admin.Close()
+
+	command := "ACL SETUSER app on >pw ~* +@all -@admin -@dangerous"
+	if got := sendTestRequest(t, admin, adminReader, command); got != "+OK\r\n" {
+		t.Fatalf("got %q, wanted +OK", got)
+	}
+	adminID := strings.TrimSpace(sendTestRequest(t, admin, adminReader, "CLIENT ID")[1:])
+	conn, reader := dialTestServer(t, server)
+	defer conn.Close()
+
+	noPerm := "-NOPERM User has no permissions to run this command\r\n"
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"AUTH app pw", "+OK\r\n"},
+		{"CLIENT SETNAME app", "+OK\r\n"},
+		{"CLIENT GETNAME", "$3\r\napp\r\n"},
+		{"CLIENT LIST", noPerm},
+		{"CLIENT KILL ID " + adminID, noPerm},
+		{"client kill 127.0.0.1:1", noPerm},
+		{"CLIENT NO-EVICT ON", noPerm},
+	}
+	for _, test := range tests {
+		if got := sendTestRequest(t, conn, reader, test.command); got != test.want {
+			t.Errorf("%s: got %q, wanted %q", test.command, got, test.want)
+		}
+	}
+
+	// The connection of the admin was not closed
+	if got := sendTestRequest(t, admin, adminReader, "CLIENT ID"); got != ":"+adminID+"\r\n" {
+		t.Errorf("got %q, wanted :%s", got, adminID)
+	}
+	got := sendTestRequest(t, admin, adminReader, "ACL CAT admin")
+	if !strings.Contains(got, "$11\r\nclient|kill\r\n") || strings.Contains(got, "$6\r\nclient\r\n") {
+		t.Errorf("got %q, wanted client|kill and not client", got)
+	}
+}
@@ -1,126 +1,94 @@
 package server
 
 import (
-	"fmt"
-	"strconv"
+	"errors"
+
+	"github.com/Devansh3712/tandb/store"
 )
 
 func (s *Server) sAdd(cmd Command) {
-	if len(cmd.Args) < 2 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
-	s.DB.SAdd(cmd.Args[0], cmd.Args[1])
+	added := 0
+	for _, member := range cmd.Args[1:] {
+		ok, err := s.DB.SAdd(cmd.Args[0], member)
+		if err != nil {
+			cmd.error(err)
+			return
+		}
+		if ok {
+			added++
+		}
+	}
+	cmd.integer(added)
 }
 
 func (s *Server) sMembers(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
 	elements, err := s.DB.SMembers(cmd.Args[0])
-	if err != nil {
+	if err != nil && !errors.Is(err, store.ErrSetNotExists) {
 		cmd.error(err)
 		return
 	}
-	for index, element := range elements {
-		cmd.write(fmt.Sprintf("%d) %s", index+1, element))
-	}
+	cmd.set(elements)
 }
 
 func (s *Server) sCard(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
 	size, err := s.DB.SCard(cmd.Args[0])
-	if err != nil {
+	if err != nil && !errors.Is(err, store.ErrSetNotExists) {
 		cmd.error(err)
 		return
 	}
-	cmd.write(strconv.Itoa(size))
+	cmd.integer(size)
 }
 
 func (s *Server) sIsMember(cmd Command) {
-	if len(cmd.Args) < 2 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
 	ok, err := s.DB.SIsMember(cmd.Args[0], cmd.Args[1])
-	if err != nil {
+	if err != nil && !errors.Is(err, store.ErrSetNotExists) {
 		cmd.error(err)
 		return
 	}
-	if !ok {
-		cmd.write("FALSE")
-		return
-	}
-	cmd.write("TRUE")
+	cmd.boolean(ok)
 }
 
 func (s *Server) sDiff(cmd Command) {
-	if len(cmd.Args) < 2 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
 	elements, err := s.DB.SDiff(cmd.Args[0], cmd.Args[1])
 	if err != nil {
 		cmd.error(err)
 		return
 	}
-	for index, element := range elements {
-		cmd.write(fmt.Sprintf("%d) %s", index+1, element))
-	}
+	cmd.set(elements)
 }
 
 func (s *Server) sDiffStore(cmd Command) {
-	if len(cmd.Args) < 3 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
-	err := s.DB.SDiffStore(cmd.Args[0], cmd.Args[1], cmd.Args[2])
+	size, err := s.DB.SDiffStore(cmd.Args[0], cmd.Args[1], cmd.Args[2])
 	if err != nil {
 		cmd.error(err)
+		return
 	}
+	cmd.integer(size)
 }
 
 func (s *Server) sInter(cmd Command) {
-	if len(cmd.Args) < 2 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
 	elements, err := s.DB.SInter(cmd.Args[0], cmd.Args[1])
 	if err != nil {
 		cmd.error(err)
 		return
 	}
-	for index, element := range elements {
-		cmd.write(fmt.Sprintf("%d) %s", index+1, element))
-	}
+	cmd.set(elements)
 }
 
 func (s *Server) sInterStore(cmd Command) {
-	if len(cmd.Args) < 3 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
-	err := s.DB.SInterStore(cmd.Args[0], cmd.Args[1], cmd.Args[2])
+	size, err := s.DB.SInterStore(cmd.Args[0], cmd.Args[1], cmd.Args[2])
 	if err != nil {
 		cmd.error(err)
+		return
 	}
+	cmd.integer(size)
 }
 
 func (s *Server) sUnion(cmd Command) {
-	if len(cmd.Args) < 2 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
 	elements, err := s.DB.SUnion(cmd.Args[0], cmd.Args[1])
 	if err != nil {
 		cmd.error(err)
 		return
 	}
-	for index, element := range elements {
-		cmd.write(fmt.Sprintf("%d) %s", index+1, element))
-	}
+	cmd.set(elements)
 }
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limits of the arguments kept in a slow log entry.
+const (
+	SlowlogMaxArgs   = 32
+	SlowlogMaxArgLen = 128
+)
+
+// SlowlogEntry is a command which took longer than the threshold.
+type SlowlogEntry struct {
+	ID       int64
+	Time     time.Time
+	Duration time.Duration
+	Args     []string
+	Addr     string
+	Name     string
+}
+
+// Slowlog keeps the latest slow commands in a ring buffer. Once the
+// buffer is full, new entries replace the oldest ones.
+type Slowlog struct {
+	Mutex   *sync.Mutex
+	entries []*SlowlogEntry
+	// Index of the next entry to write
+	next   int
+	count  int
+	nextID int64
+	// Minimum duration of logged commands, in microseconds
+	threshold atomic.Int64
+}
+
+func NewSlowlog() *Slowlog {
+	slowlog := &Slowlog{Mutex: &sync.Mutex{}}
+	slowlog.threshold.Store(-1)
+	return slowlog
+}
+
+// Set the minimum duration of logged commands in microseconds.
+// A negative threshold disables the slow log.
+func (l *Slowlog) SetThreshold(micros int64) {
+	l.threshold.Store(micros)
+}
+
+// Resize the buffer, keeping the latest entries which fit.
+func (l *Slowlog) SetMaxLen(maxLen int) {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+
+	entries := l.latest(-1)
+	if len(entries) > maxLen {
+		entries = entries[:maxLen]
+	}
+	l.entries = make([]*SlowlogEntry, maxLen)
+	l.count = len(entries)
+	l.next = 0
+	for i := len(entries) - 1; i >= 0; i-- {
+		l.entries[l.next] = entries[i]
+		l.next = (l.next + 1) % maxLen
+	}
+}
+
+// Log a command if it ran for longer than the threshold.
+func (l *Slowlog) Record(cmd Command, start time.Time, duration time.Duration) {
+	threshold := l.threshold.Load()
+	if threshold < 0 || duration.Microseconds() < threshold {
+		return
+	}
+
+	entry := &SlowlogEntry{
+		Time:     start,
+		Duration: duration,
+		Args:     slowlogArgs(cmd),
+		Addr:     cmd.Client.Addr,
+		Name:     cmd.Client.GetName(),
+	}
+
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+
+	entry.ID = l.nextID
+	l.nextID++
+	if len(l.entries) == 0 {
+		return
+	}
+	l.entries[l.next] = entry
+	l.next = (l.next + 1) % len(l.entries)
+	if l.count < len(l.entries) {
+		l.count++
+	}
+}
+
+// Returns up to count entries, from the newest to the oldest.
+// A negative count returns every entry.
+func (l *Slowlog) Get(count int) []*SlowlogEntry {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+
+	return l.latest(count)
+}
+
+func (l *Slowlog) latest(count int) []*SlowlogEntry {
+	if count < 0 || count > l.count {
+		count = l.count
+	}
+	entries := make([]*SlowlogEntry, count)
+	for i := range entries {
+		index := (l.next - 1 - i + 2*len(l.entries)) % len(l.entries)
+		entries[i] = l.entries[index]
+	}
+	return entries
+}
+
+func (l *Slowlog) Len() int {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+
+	return l.count
+}
+
+// Remove every entry. IDs keep increasing.
+func (l *Slowlog) Reset() {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+
+	clear(l.entries)
+	l.next, l.count = 0, 0
+}
+
+// Returns the command name and arguments of a command, redacted as
+// in MONITOR and truncated so that large commands do not fill the
+// memory of the slow log.
+func slowlogArgs(cmd Command) []string {
+	all := redactArgs(cmd)
+	args := make([]string, 0, min(len(all), SlowlogMaxArgs))
+	for i, arg := range all {
+		if i == SlowlogMaxArgs-1 && len(all) > SlowlogMaxArgs {
+			args = append(args, fmt.Sprintf("... (%d more arguments)", len(all)-i))
+			break
+		}
+		if len(arg) > SlowlogMaxArgLen {
+			arg = fmt.Sprintf("%s... (%d more bytes)", arg[:SlowlogMaxArgLen], len(arg)-SlowlogMaxArgLen)
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+// SLOWLOG GET [count] | LEN | RESET
+func (s *Server) slowlog(cmd Command) {
+	sub := strings.ToUpper(cmd.Args[0])
+	args := cmd.Args[1:]
+
+	switch sub {
+	case "GET":
+		if len(args) > 1 {
+			cmd.error(wrongArity("slowlog|get"))
+			return
+		}
+		count := 10
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < -1 {
+				cmd.error(fmt.Errorf("count should be greater than or equal to -1"))
+				return
+			}
+			count = n
+		}
+
+		entries := s.Slowlog.Get(count)
+		writer := cmd.Client.Writer
+		cmd.logError(writer.WriteArray(len(entries)))
+		for _, entry := range entries {
+			cmd.logError(writer.WriteArray(6))
+			cmd.logError(writer.WriteInteger(entry.ID))
+			cmd.logError(writer.WriteInteger(entry.Time.Unix()))
+			cmd.logError(writer.WriteInteger(entry.Duration.Microseconds()))
+			cmd.array(entry.Args)
+			cmd.logError(writer.WriteBulkString(entry.Addr))
+			cmd.logError(writer.WriteBulkString(entry.Name))
+		}
+	case "LEN":
+		if len(args) != 0 {
+			cmd.error(wrongArity("slowlog|len"))
+			return
+		}
+		cmd.integer(s.Slowlog.Len())
+	case "RESET":
+		if len(args) != 0 {
+			cmd.error(wrongArity("slowlog|reset"))
+			return
+		}
+		s.Slowlog.Reset()
+		cmd.ok()
+	default:
+		cmd.error(unknownSubcommand(CMD_SLOWLOG, sub))
+	}
+}
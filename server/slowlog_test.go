@@ -0,0 +1,95 @@
+package server
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSlowlog(t *testing.T) {
+	slowlog := NewSlowlog()
+	slowlog.SetThreshold(1000)
+	slowlog.SetMaxLen(3)
+	client := &Client{Addr: "127.0.0.1:5000", Mutex: &sync.RWMutex{}}
+
+	for i, duration := range []time.Duration{0, 2, 3, 4, 5} {
+		cmd := Command{Value: "GET", Args: []string{strings.Repeat("k", i)}, Client: client}
+		slowlog.Record(cmd, time.Now(), duration*time.Millisecond)
+	}
+	if got := slowlog.Len(); got != 3 {
+		t.Errorf("got %d, wanted %d", got, 3)
+	}
+	entries := slowlog.Get(-1)
+	for i, want := range []int64{3, 2, 1} {
+		if entries[i].ID != want {
+			t.Errorf("got %d, wanted %d", entries[i].ID, want)
+		}
+	}
+
+	// Shrinking the buffer keeps the latest entries
+	slowlog.SetMaxLen(1)
+	if got := slowlog.Get(10)[0].ID; got != 3 {
+		t.Errorf("got %d, wanted %d", got, 3)
+	}
+
+	args := make([]string, 40)
+	args[0] = strings.Repeat("v", 200)
+	slowlog.Record(Command{Value: "MGET", Args: args, Client: client}, time.Now(), time.Second)
+	got := slowlog.Get(1)[0].Args
+	if len(got) != SlowlogMaxArgs {
+		t.Errorf("got %d, wanted %d", len(got), SlowlogMaxArgs)
+	}
+	if want := "... (10 more arguments)"; got[len(got)-1] != want {
+		t.Errorf("got %q, wanted %q", got[len(got)-1], want)
+	}
+	if want := strings.Repeat("v", 128) + "... (72 more bytes)"; got[1] != want {
+		t.Errorf("got %q, wanted %q", got[1], want)
+	}
+
+	slowlog.Reset()
+	if got := slowlog.Len(); got != 0 {
+		t.Errorf("got %d, wanted %d", got, 0)
+	}
+}
+
+func TestSlowlogRedaction(t *testing.T) {
+	slowlog := NewSlowlog()
+	slowlog.SetThreshold(0)
+	slowlog.SetMaxLen(10)
+	client := &Client{Addr: "127.0.0.1:5000", Mutex: &sync.RWMutex{}}
+
+	tests := []struct {
+		cmd  Command
+		want []string
+	}{
+		{
+			Command{Value: "AUTH", Args: []string{"user", "secret"}},
+			[]string{"auth", redacted, redacted},
+		},
+		{
+			Command{Value: "HELLO", Args: []string{"3", "AUTH", "user", "secret", "SETNAME", "name"}},
+			[]string{"hello", "3", "AUTH", redacted, redacted, "SETNAME", "name"},
+		},
+		{
+			Command{Value: "ACL", Args: []string{"SETUSER", "user", "on", ">secret"}},
+			[]string{"acl", "SETUSER", "user", redacted, redacted},
+		},
+		{
+			Command{Value: "CONFIG", Args: []string{"SET", "maxclients", "10", "requirepass", "secret"}},
+			[]string{"config", "SET", "maxclients", "10", "requirepass", redacted},
+		},
+		{
+			Command{Value: "GET", Args: []string{"secret"}},
+			[]string{"get", "secret"},
+		},
+	}
+	for _, test := range tests {
+		test.cmd.Client = client
+		slowlog.Record(test.cmd, time.Now(), time.Millisecond)
+		if got := slowlog.Get(1)[0].Args; !slices.Equal(got, test.want) {
+			t.Errorf("got %q, wanted %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Upper bounds of the buckets of the command latency histograms.
+var latencyBuckets = []time.Duration{
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// CommandStats counts the calls of a command and their latency.
+// Buckets are not cumulative, the last one counts calls slower
+// than every bound.
+type CommandStats struct {
+	Calls    atomic.Int64
+	Duration atomic.Int64
+	Buckets  []atomic.Int64
+}
+
+func newCommandStats() *CommandStats {
+	return &CommandStats{Buckets: make([]atomic.Int64, len(latencyBuckets)+1)}
+}
+
+// Record a call of the command.
+func (c *CommandStats) observe(duration time.Duration) {
+	c.Calls.Add(1)
+	c.Duration.Add(int64(duration))
+	bucket := sort.Search(len(latencyBuckets), func(i int) bool {
+		return duration <= latencyBuckets[i]
+	})
+	c.Buckets[bucket].Add(1)
+}
+
+func (c *CommandStats) reset() {
+	c.Calls.Store(0)
+	c.Duration.Store(0)
+	for i := range c.Buckets {
+		c.Buckets[i].Store(0)
+	}
+}
+
+// Create the statistics of every command of the command table.
+// The map is never modified afterwards, so it is read without a lock.
+func newCommandStatsTable() map[string]*CommandStats {
+	stats := make(map[string]*CommandStats, len(commandTable))
+	for name := range commandTable {
+		stats[name] = newCommandStats()
+	}
+	return stats
+}
+
+// ErrorStats counts the error replies sent to clients by their
+// error code, such as ERR or WRONGTYPE.
+type ErrorStats struct {
+	Mutex  *sync.Mutex
+	Counts map[string]int64
+}
+
+func newErrorStats() *ErrorStats {
+	return &ErrorStats{Mutex: &sync.Mutex{}, Counts: make(map[string]int64)}
+}
+
+// Count an error reply by the first word of its message.
+func (e *ErrorStats) add(msg string) {
+	code, _, _ := strings.Cut(msg, " ")
+
+	e.Mutex.Lock()
+	defer e.Mutex.Unlock()
+
+	e.Counts[code]++
+}
+
+// Returns a copy of the counts.
+func (e *ErrorStats) snapshot() map[string]int64 {
+	e.Mutex.Lock()
+	defer e.Mutex.Unlock()
+
+	counts := make(map[string]int64, len(e.Counts))
+	for code, count := range e.Counts {
+		counts[code] = count
+	}
+	return counts
+}
+
+func (e *ErrorStats) reset() {
+	e.Mutex.Lock()
+	defer e.Mutex.Unlock()
+
+	e.Counts = make(map[string]int64)
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Devansh3712/tandb/store"
+)
+
+var (
+	ErrNotFloat      = store.ErrNotFloat
+	ErrStringTooLong = store.ErrStringTooLong
+)
+
+func (s *Server) incr(cmd Command) {
+	s.addInteger(cmd, 1)
+}
+
+func (s *Server) decr(cmd Command) {
+	s.addInteger(cmd, -1)
+}
+
+// INCRBY key increment
+func (s *Server) incrBy(cmd Command) {
+	delta, err := strconv.ParseInt(cmd.Args[1], 10, 64)
+	if err != nil {
+		cmd.error(ErrNotInteger)
+		return
+	}
+	s.addInteger(cmd, delta)
+}
+
+// DECRBY key decrement
+func (s *Server) decrBy(cmd Command) {
+	delta, err := strconv.ParseInt(cmd.Args[1], 10, 64)
+	if err != nil {
+		cmd.error(ErrNotInteger)
+		return
+	}
+	if delta == math.MinInt64 {
+		cmd.error(errors.New("decrement would overflow"))
+		return
+	}
+	s.addInteger(cmd, -delta)
+}
+
+func (s *Server) addInteger(cmd Command, delta int64) {
+	n, err := s.DB.IncrBy(cmd.Args[0], delta)
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.logError(cmd.Client.Writer.WriteInteger(n))
+}
+
+// INCRBYFLOAT key increment
+func (s *Server) incrByFloat(cmd Command) {
+	value, err := s.DB.IncrByFloat(cmd.Args[0], cmd.Args[1])
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.bulk(value)
+}
+
+// APPEND key value
+func (s *Server) appendValue(cmd Command) {
+	maxLen := s.Config.Int("proto-max-bulk-len")
+	length, err := s.DB.Append(cmd.Args[0], []byte(cmd.Args[1]), maxLen)
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.integer(length)
+}
+
+// STRLEN key
+func (s *Server) strLen(cmd Command) {
+	length, err := s.DB.StrLen(cmd.Args[0])
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.integer(length)
+}
+
+// GETRANGE key start end
+func (s *Server) getRange(cmd Command) {
+	start, err := strconv.Atoi(cmd.Args[1])
+	if err != nil {
+		cmd.error(ErrNotInteger)
+		return
+	}
+	end, err := strconv.Atoi(cmd.Args[2])
+	if err != nil {
+		cmd.error(ErrNotInteger)
+		return
+	}
+	value, err := s.DB.GetRange(cmd.Args[0], start, end)
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.bulk(value)
+}
+
+// SETRANGE key offset value
+func (s *Server) setRange(cmd Command) {
+	offset, err := strconv.Atoi(cmd.Args[1])
+	if err != nil {
+		cmd.error(ErrNotInteger)
+		return
+	}
+	if offset < 0 {
+		cmd.error(errors.New("offset is out of range"))
+		return
+	}
+	if offset+len(cmd.Args[2]) > s.Config.Int("proto-max-bulk-len") {
+		cmd.error(ErrStringTooLong)
+		return
+	}
+	length, err := s.DB.SetRange(cmd.Args[0], offset, []byte(cmd.Args[2]))
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	cmd.integer(length)
+}
+
+// A range of bytes matching in both strings of LCS.
+type lcsMatch struct {
+	a, b [2]int
+}
+
+func (m lcsMatch) len() int {
+	return m.a[1] - m.a[0] + 1
+}
+
+// Find the longest common subsequence of two strings, along with the
+// ranges of both strings making it up, from the last to the first.
+func lcs(a, b []byte) ([]byte, []lcsMatch) {
+	// lengths[i*width+j] is the length of the LCS of a[:i] and b[:j]
+	width := len(b) + 1
+	lengths := make([]uint32, (len(a)+1)*width)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				lengths[i*width+j] = lengths[(i-1)*width+j-1] + 1
+			} else {
+				lengths[i*width+j] = max(lengths[(i-1)*width+j], lengths[i*width+j-1])
+			}
+		}
+	}
+
+	result := make([]byte, lengths[len(a)*width+len(b)])
+	var matches []lcsMatch
+	var current *lcsMatch
+	for i, j, k := len(a), len(b), len(result); i > 0 && j > 0; {
+		if a[i-1] == b[j-1] {
+			k--
+			result[k] = a[i-1]
+			i, j = i-1, j-1
+			// Extend the current range backward while it is contiguous
+			if current != nil && current.a[0] == i+1 && current.b[0] == j+1 {
+				current.a[0], current.b[0] = i, j
+			} else {
+				if current != nil {
+					matches = append(matches, *current)
+				}
+				current = &lcsMatch{a: [2]int{i, i}, b: [2]int{j, j}}
+			}
+			continue
+		}
+		if lengths[(i-1)*width+j] > lengths[i*width+j-1] {
+			i--
+		} else {
+			j--
+		}
+		if current != nil {
+			matches = append(matches, *current)
+			current = nil
+		}
+	}
+	if current != nil {
+		matches = append(matches, *current)
+	}
+	return result, matches
+}
+
+// LCS key1 key2 [LEN] [IDX] [MINMATCHLEN min-match-len] [WITHMATCHLEN]
+func (s *Server) lcs(cmd Command) {
+	var getLen, getIdx, withMatchLen bool
+	minMatchLen := 0
+	for i := 2; i < len(cmd.Args); i++ {
+		switch strings.ToUpper(cmd.Args[i]) {
+		case "LEN":
+			getLen = true
+		case "IDX":
+			getIdx = true
+		case "WITHMATCHLEN":
+			withMatchLen = true
+		case "MINMATCHLEN":
+			if i+1 == len(cmd.Args) {
+				cmd.error(ErrSyntax)
+				return
+			}
+			i++
+			n, err := strconv.Atoi(cmd.Args[i])
+			if err != nil {
+				cmd.error(ErrNotInteger)
+				return
+			}
+			minMatchLen = max(n, 0)
+		default:
+			cmd.error(ErrSyntax)
+			return
+		}
+	}
+	if getLen && getIdx {
+		cmd.error(errors.New("If you want both the length and indexes, please just use IDX."))
+		return
+	}
+
+	a, err := s.DB.GetString(cmd.Args[0])
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	b, err := s.DB.GetString(cmd.Args[1])
+	if err != nil {
+		cmd.error(err)
+		return
+	}
+	// The table of lengths takes 4 bytes per pair of positions
+	if (len(a)+1)*(len(b)+1) > s.Config.Int("proto-max-bulk-len")/4 {
+		cmd.error(errors.New("Insufficient memory, transient memory for LCS exceeds proto-max-bulk-len"))
+		return
+	}
+
+	result, matches := lcs(a, b)
+	switch {
+	case getLen:
+		cmd.integer(len(result))
+	case getIdx:
+		writer := cmd.Client.Writer
+		cmd.logError(writer.WriteMap(2))
+		cmd.logError(writer.WriteBulkString("matches"))
+		var kept []lcsMatch
+		for _, match := range matches {
+			if match.len() >= minMatchLen {
+				kept = append(kept, match)
+			}
+		}
+		cmd.logError(writer.WriteArray(len(kept)))
+		for _, match := range kept {
+			if withMatchLen {
+				cmd.logError(writer.WriteArray(3))
+			} else {
+				cmd.logError(writer.WriteArray(2))
+			}
+			for _, r := range [][2]int{match.a, match.b} {
+				cmd.logError(writer.WriteArray(2))
+				cmd.logError(writer.WriteInteger(int64(r[0])))
+				cmd.logError(writer.WriteInteger(int64(r[1])))
+			}
+			if withMatchLen {
+				cmd.integer(match.len())
+			}
+		}
+		cmd.logError(writer.WriteBulkString("len"))
+		cmd.integer(len(result))
+	default:
+		cmd.bulk(result)
+	}
+}
@@ -0,0 +1,611 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Command flags, describing the behaviour of a command.
+const (
+	FlagWrite = 1 << iota
+	FlagReadonly
+	FlagAdmin
+	FlagBlocking
+	FlagFast
+	// Commands which can run before the client has authenticated
+	FlagNoAuth
+)
+
+var flagNames = []struct {
+	flag int
+	name string
+}{
+	{FlagWrite, "write"},
+	{FlagReadonly, "readonly"},
+	{FlagAdmin, "admin"},
+	{FlagBlocking, "blocking"},
+	{FlagFast, "fast"},
+	{FlagNoAuth, "no-auth"},
+}
+
+// CommandSpec describes a command and how it is dispatched.
+//
+// Arity counts the command name itself. A positive arity is the
+// exact number of arguments, a negative arity is the minimum.
+// Key positions are also counted from the command name, so the
+// first argument is at position 1. A LastKey of -1 means the last
+// argument, and a FirstKey of 0 means the command takes no keys.
+//
+// Subcommands whose permissions differ from the command's have their
+// own spec, keyed by their upper case name, which only needs a name,
+// flags and categories.
+type CommandSpec struct {
+	Name        string
+	Arity       int
+	Flags       int
+	FirstKey    int
+	LastKey     int
+	Step        int
+	Categories  []string
+	Group       string
+	Summary     string
+	Handler     func(*Server, Command)
+	Subcommands map[string]*CommandSpec
+
+	aclCategories []string
+}
+
+var commandTable = map[string]*CommandSpec{}
+
+func registerCommands(specs ...*CommandSpec) {
+	for _, spec := range specs {
+		spec.aclCategories = spec.buildACLCategories()
+		for _, sub := range spec.Subcommands {
+			sub.aclCategories = sub.buildACLCategories()
+		}
+		commandTable[spec.Name] = spec
+	}
+}
+
+// Find the spec of a command by its case-insensitive name.
+func LookupCommand(name string) (*CommandSpec, bool) {
+	spec, ok := commandTable[strings.ToUpper(name)]
+	return spec, ok
+}
+
+// Returns the specs of all commands sorted by name.
+func Commands() []*CommandSpec {
+	specs := make([]*CommandSpec, 0, len(commandTable))
+	for _, spec := range commandTable {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+func commandExists(name string) bool {
+	_, ok := LookupCommand(name)
+	return ok
+}
+
+func (c *CommandSpec) checkArity(argc int) bool {
+	if c.Arity >= 0 {
+		return argc == c.Arity
+	}
+	return argc >= -c.Arity
+}
+
+// Returns the spec of the subcommand in the arguments if it has its
+// own, or the spec of the command.
+func (c *CommandSpec) subcommand(args []string) *CommandSpec {
+	if len(args) > 0 {
+		if sub, ok := c.Subcommands[strings.ToUpper(args[0])]; ok {
+			return sub
+		}
+	}
+	return c
+}
+
+func (c *CommandSpec) HasFlag(flag int) bool {
+	return c.Flags&flag != 0
+}
+
+// Returns the names of the flags set on the command.
+func (c *CommandSpec) FlagNames() []string {
+	var names []string
+	for _, f := range flagNames {
+		if c.HasFlag(f.flag) {
+			names = append(names, f.name)
+		}
+	}
+	return names
+}
+
+// Returns the ACL categories of the command, including the ones
+// implied by its flags. They are computed when the command is
+// registered, and must not be modified.
+func (c *CommandSpec) ACLCategories() []string {
+	return c.aclCategories
+}
+
+func (c *CommandSpec) buildACLCategories() []string {
+	categories := append([]string{}, c.Categories...)
+	if c.HasFlag(FlagWrite) {
+		categories = append(categories, "write")
+	}
+	if c.HasFlag(FlagReadonly) {
+		categories = append(categories, "read")
+	}
+	if c.HasFlag(FlagAdmin) {
+		categories = append(categories, "admin", "dangerous")
+	}
+	if c.HasFlag(FlagBlocking) {
+		categories = append(categories, "blocking")
+	}
+	if c.HasFlag(FlagFast) {
+		categories = append(categories, "fast")
+	} else {
+		categories = append(categories, "slow")
+	}
+	return categories
+}
+
+// Extract the keys from the arguments of a command, not
+// including the command name.
+func (c *CommandSpec) Keys(args []string) []string {
+	if c.FirstKey <= 0 {
+		return nil
+	}
+	last := c.LastKey
+	if last < 0 {
+		last = len(args) + 1 + last
+	}
+	if last > len(args) {
+		last = len(args)
+	}
+	step := c.Step
+	if step <= 0 {
+		step = 1
+	}
+
+	var keys []string
+	for i := c.FirstKey; i <= last; i += step {
+		keys = append(keys, args[i-1])
+	}
+	return keys
+}
+
+func wrongArity(name string) error {
+	return fmt.Errorf("wrong number of arguments for '%s' command", strings.ToLower(name))
+}
+
+func unknownCommand(cmd Command) error {
+	var args []string
+	for _, arg := range cmd.Args {
+		args = append(args, "'"+arg+"'")
+	}
+	return fmt.Errorf(
+		"unknown command '%s', with args beginning with: %s",
+		cmd.Value, strings.Join(args, " "),
+	)
+}
+
+func unknownSubcommand(name, sub string) error {
+	return fmt.Errorf(
+		"unknown subcommand '%s' for '%s' command",
+		strings.ToLower(sub), strings.ToLower(name),
+	)
+}
+
+func init() {
+	registerCommands(
+		// Generic commands
+		&CommandSpec{
+			Name: CMD_GET, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Returns the string value of a key.",
+			Handler: (*Server).get,
+		},
+		&CommandSpec{
+			Name: CMD_DEL, Arity: -2, Flags: FlagWrite,
+			FirstKey: 1, LastKey: -1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Deletes one or more keys.",
+			Handler: (*Server).del,
+		},
+		&CommandSpec{
+			Name: CMD_RENAME, Arity: 3, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 2, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Renames a key and overwrites the destination.",
+			Handler: (*Server).rename,
+		},
+		&CommandSpec{
+			Name: CMD_RENAMENX, Arity: 3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 2, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Renames a key only when the target key name doesn't exist.",
+			Handler: (*Server).renameNX,
+		},
+		&CommandSpec{
+			Name: CMD_COPY, Arity: -3, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 2, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Copies the value of a key to a new key.",
+			Handler: (*Server).copyKey,
+		},
+		&CommandSpec{
+			Name: CMD_SET, Arity: -3, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Sets the string value of a key, ignoring its type. The key is created if it doesn't exist.",
+			Handler: (*Server).set,
+		},
+		&CommandSpec{
+			Name: CMD_SETNX, Arity: 3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Sets the string value of a key only when the key doesn't exist.",
+			Handler: (*Server).setNX,
+		},
+		&CommandSpec{
+			Name: CMD_TTL, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Returns the expiration time in seconds of a key.",
+			Handler: (*Server).ttl,
+		},
+		&CommandSpec{
+			Name: CMD_PTTL, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Returns the expiration time in milliseconds of a key.",
+			Handler: (*Server).pTTL,
+		},
+		&CommandSpec{
+			Name: CMD_KEYS, Arity: 2, Flags: FlagReadonly,
+			Categories: []string{"keyspace", "dangerous"},
+			Group:      "generic", Summary: "Returns all key names.",
+			Handler: (*Server).keys,
+		},
+		&CommandSpec{
+			Name: CMD_MGET, Arity: -2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: -1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Atomically returns the string values of one or more keys.",
+			Handler: (*Server).mGet,
+		},
+		&CommandSpec{
+			Name: CMD_MSET, Arity: -3, Flags: FlagWrite,
+			FirstKey: 1, LastKey: -1, Step: 2, Categories: []string{"string"},
+			Group: "string", Summary: "Atomically creates or modifies the string values of one or more keys.",
+			Handler: (*Server).mSet,
+		},
+		&CommandSpec{
+			Name: CMD_MSETNX, Arity: -3, Flags: FlagWrite,
+			FirstKey: 1, LastKey: -1, Step: 2, Categories: []string{"string"},
+			Group: "string", Summary: "Atomically modifies the string values of one or more keys only when all keys don't exist.",
+			Handler: (*Server).mSetNX,
+		},
+		&CommandSpec{
+			Name: CMD_GETDEL, Arity: 2, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Returns the string value of a key after deleting the key.",
+			Handler: (*Server).getDel,
+		},
+		&CommandSpec{
+			Name: CMD_GETSET, Arity: 3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Returns the previous string value of a key after setting it to a new value.",
+			Handler: (*Server).getSet,
+		},
+		&CommandSpec{
+			Name: CMD_GETEX, Arity: -2, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Returns the string value of a key after setting its expiration time.",
+			Handler: (*Server).getEx,
+		},
+		&CommandSpec{
+			Name: CMD_SETEX, Arity: 4, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Sets the string value and expiration time of a key.",
+			Handler: (*Server).setEx,
+		},
+		&CommandSpec{
+			Name: CMD_PSETEX, Arity: 4, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Sets both string value and expiration time in milliseconds of a key.",
+			Handler: (*Server).pSetEx,
+		},
+		&CommandSpec{
+			Name: CMD_EXISTS, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Determines whether a key exists.",
+			Handler: (*Server).exists,
+		},
+		&CommandSpec{
+			Name: CMD_TYPE, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Determines the type of value stored at a key.",
+			Handler: (*Server).keyType,
+		},
+		&CommandSpec{
+			Name: CMD_EXPIRE, Arity: -3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Sets the expiration time of a key in seconds.",
+			Handler: (*Server).expire,
+		},
+		&CommandSpec{
+			Name: CMD_PEXPIRE, Arity: -3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Sets the expiration time of a key in milliseconds.",
+			Handler: (*Server).pExpire,
+		},
+		&CommandSpec{
+			Name: CMD_EXPIRE_AT, Arity: -3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Sets the expiration time of a key to a Unix timestamp.",
+			Handler: (*Server).expireAt,
+		},
+		&CommandSpec{
+			Name: CMD_PEXPIRE_AT, Arity: -3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Sets the expiration time of a key to a Unix milliseconds timestamp.",
+			Handler: (*Server).pExpireAt,
+		},
+		&CommandSpec{
+			Name: CMD_PERSIST, Arity: 2, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Removes the expiration time of a key.",
+			Handler: (*Server).persist,
+		},
+		&CommandSpec{
+			Name: CMD_EXPIRE_TIME, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Returns the expiration time of a key as a Unix timestamp.",
+			Handler: (*Server).expireTime,
+		},
+		&CommandSpec{
+			Name: CMD_PEXPIRE_TIME, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"keyspace"},
+			Group: "generic", Summary: "Returns the expiration time of a key as a Unix milliseconds timestamp.",
+			Handler: (*Server).pExpireTime,
+		},
+		// String commands
+		&CommandSpec{
+			Name: CMD_INCR, Arity: 2, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Increments the integer value of a key by one. Uses 0 as initial value if the key doesn't exist.",
+			Handler: (*Server).incr,
+		},
+		&CommandSpec{
+			Name: CMD_DECR, Arity: 2, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Decrements the integer value of a key by one. Uses 0 as initial value if the key doesn't exist.",
+			Handler: (*Server).decr,
+		},
+		&CommandSpec{
+			Name: CMD_INCRBY, Arity: 3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Increments the integer value of a key by a number. Uses 0 as initial value if the key doesn't exist.",
+			Handler: (*Server).incrBy,
+		},
+		&CommandSpec{
+			Name: CMD_DECRBY, Arity: 3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Decrements a number from the integer value of a key. Uses 0 as initial value if the key doesn't exist.",
+			Handler: (*Server).decrBy,
+		},
+		&CommandSpec{
+			Name: CMD_INCRBYFLOAT, Arity: 3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Increment the floating point value of a key by a number. Uses 0 as initial value if the key doesn't exist.",
+			Handler: (*Server).incrByFloat,
+		},
+		&CommandSpec{
+			Name: CMD_APPEND, Arity: 3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Appends a string to the value of a key. Creates the key if it doesn't exist.",
+			Handler: (*Server).appendValue,
+		},
+		&CommandSpec{
+			Name: CMD_STRLEN, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Returns the length of a string value.",
+			Handler: (*Server).strLen,
+		},
+		&CommandSpec{
+			Name: CMD_GETRANGE, Arity: 4, Flags: FlagReadonly,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Returns a substring of the string stored at a key.",
+			Handler: (*Server).getRange,
+		},
+		&CommandSpec{
+			Name: CMD_SETRANGE, Arity: 4, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Overwrites a part of a string value with another by an offset. Creates the key if it doesn't exist.",
+			Handler: (*Server).setRange,
+		},
+		&CommandSpec{
+			Name: CMD_LCS, Arity: -3, Flags: FlagReadonly,
+			FirstKey: 1, LastKey: 2, Step: 1, Categories: []string{"string"},
+			Group: "string", Summary: "Finds the longest common substring.",
+			Handler: (*Server).lcs,
+		},
+		// Bitmap commands
+		&CommandSpec{
+			Name: CMD_SETBIT, Arity: 4, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"bitmap"},
+			Group: "bitmap", Summary: "Sets or clears the bit at offset of the string value. Creates the key if it doesn't exist.",
+			Handler: (*Server).setBit,
+		},
+		&CommandSpec{
+			Name: CMD_GETBIT, Arity: 3, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"bitmap"},
+			Group: "bitmap", Summary: "Returns a bit value by offset.",
+			Handler: (*Server).getBit,
+		},
+		&CommandSpec{
+			Name: CMD_BITCOUNT, Arity: -2, Flags: FlagReadonly,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"bitmap"},
+			Group: "bitmap", Summary: "Counts the number of set bits (population counting) in a string.",
+			Handler: (*Server).bitCount,
+		},
+		&CommandSpec{
+			Name: CMD_BITPOS, Arity: -3, Flags: FlagReadonly,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"bitmap"},
+			Group: "bitmap", Summary: "Finds the first set (1) or clear (0) bit in a string.",
+			Handler: (*Server).bitPos,
+		},
+		&CommandSpec{
+			Name: CMD_BITOP, Arity: -4, Flags: FlagWrite,
+			FirstKey: 2, LastKey: -1, Step: 1, Categories: []string{"bitmap"},
+			Group: "bitmap", Summary: "Performs bitwise operations on multiple strings, and stores the result.",
+			Handler: (*Server).bitOp,
+		},
+		&CommandSpec{
+			Name: CMD_BITFIELD, Arity: -2, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"bitmap"},
+			Group: "bitmap", Summary: "Performs arbitrary bitfield integer operations on strings.",
+			Handler: (*Server).bitField,
+		},
+		&CommandSpec{
+			Name: CMD_BITFIELD_RO, Arity: -2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"bitmap"},
+			Group: "bitmap", Summary: "Performs arbitrary read-only bitfield integer operations on strings.",
+			Handler: (*Server).bitFieldRO,
+		},
+		// Connection commands
+		&CommandSpec{
+			Name: CMD_AUTH, Arity: -2, Flags: FlagFast | FlagNoAuth,
+			Categories: []string{"connection"},
+			Group:      "connection", Summary: "Authenticates the connection.",
+			Handler: (*Server).auth,
+		},
+		&CommandSpec{
+			Name: CMD_HELLO, Arity: -1, Flags: FlagFast | FlagNoAuth,
+			Categories: []string{"connection"},
+			Group:      "connection", Summary: "Handshakes with the server and switches the protocol.",
+			Handler: (*Server).hello,
+		},
+		&CommandSpec{
+			Name: CMD_CLIENT, Arity: -2,
+			Categories: []string{"connection"},
+			Group:      "connection", Summary: "Inspects and manages client connections.",
+			Handler: (*Server).client,
+			// Other connections can be listed and closed
+			Subcommands: map[string]*CommandSpec{
+				"LIST":     {Name: "client|list", Flags: FlagAdmin, Categories: []string{"connection"}},
+				"KILL":     {Name: "client|kill", Flags: FlagAdmin, Categories: []string{"connection"}},
+				"NO-EVICT": {Name: "client|no-evict", Flags: FlagAdmin, Categories: []string{"connection"}},
+			},
+		},
+		// Server commands
+		&CommandSpec{
+			Name: CMD_COMMAND, Arity: -1,
+			Categories: []string{"connection"},
+			Group:      "server", Summary: "Returns detailed information about commands.",
+			Handler: (*Server).command,
+		},
+		&CommandSpec{
+			Name: CMD_INFO, Arity: -1,
+			Categories: []string{"dangerous"},
+			Group:      "server", Summary: "Returns information and statistics about the server.",
+			Handler: (*Server).info,
+		},
+		&CommandSpec{
+			Name: CMD_SLOWLOG, Arity: -2, Flags: FlagAdmin,
+			Group: "server", Summary: "Returns, counts or resets the slow log entries.",
+			Handler: (*Server).slowlog,
+		},
+		&CommandSpec{
+			Name: CMD_MONITOR, Arity: 1, Flags: FlagAdmin,
+			Group: "server", Summary: "Listens for all requests received by the server in real-time.",
+			Handler: (*Server).monitor,
+		},
+		&CommandSpec{
+			Name: CMD_CONFIG, Arity: -2, Flags: FlagAdmin,
+			Group: "server", Summary: "Gets, sets, resets the statistics of or rewrites the configuration.",
+			Handler: (*Server).config,
+		},
+		&CommandSpec{
+			Name: CMD_SHUTDOWN, Arity: -1, Flags: FlagAdmin,
+			Group: "server", Summary: "Synchronously saves the database and shuts down the server.",
+			Handler: (*Server).shutdown,
+		},
+		// ACL commands
+		&CommandSpec{
+			Name: CMD_ACL, Arity: -2, Flags: FlagAdmin,
+			Group: "server", Summary: "Manages users and their permissions.",
+			Handler: (*Server).acl,
+		},
+		// Set commands
+		&CommandSpec{
+			Name: CMD_SADD, Arity: -3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"set"},
+			Group: "set", Summary: "Adds one or more members to a set.",
+			Handler: (*Server).sAdd,
+		},
+		&CommandSpec{
+			Name: CMD_SCARD, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"set"},
+			Group: "set", Summary: "Returns the number of members in a set.",
+			Handler: (*Server).sCard,
+		},
+		&CommandSpec{
+			Name: CMD_SDIFF, Arity: 3, Flags: FlagReadonly,
+			FirstKey: 1, LastKey: 2, Step: 1, Categories: []string{"set"},
+			Group: "set", Summary: "Returns the difference of two sets.",
+			Handler: (*Server).sDiff,
+		},
+		&CommandSpec{
+			Name: CMD_SINTER, Arity: 3, Flags: FlagReadonly,
+			FirstKey: 1, LastKey: 2, Step: 1, Categories: []string{"set"},
+			Group: "set", Summary: "Returns the intersection of two sets.",
+			Handler: (*Server).sInter,
+		},
+		&CommandSpec{
+			Name: CMD_SUNION, Arity: 3, Flags: FlagReadonly,
+			FirstKey: 1, LastKey: 2, Step: 1, Categories: []string{"set"},
+			Group: "set", Summary: "Returns the union of two sets.",
+			Handler: (*Server).sUnion,
+		},
+		&CommandSpec{
+			Name: CMD_SMEMBERS, Arity: 2, Flags: FlagReadonly,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"set"},
+			Group: "set", Summary: "Returns all members of a set.",
+			Handler: (*Server).sMembers,
+		},
+		&CommandSpec{
+			Name: CMD_SISMEMBER, Arity: 3, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"set"},
+			Group: "set", Summary: "Determines whether a member belongs to a set.",
+			Handler: (*Server).sIsMember,
+		},
+		&CommandSpec{
+			Name: CMD_SDIFFSTORE, Arity: 4, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 3, Step: 1, Categories: []string{"set"},
+			Group: "set", Summary: "Stores the difference of two sets in a key.",
+			Handler: (*Server).sDiffStore,
+		},
+		&CommandSpec{
+			Name: CMD_SINTERSTORE, Arity: 4, Flags: FlagWrite,
+			FirstKey: 1, LastKey: 3, Step: 1, Categories: []string{"set"},
+			Group: "set", Summary: "Stores the intersection of two sets in a key.",
+			Handler: (*Server).sInterStore,
+		},
+		// Sorted set commands
+		&CommandSpec{
+			Name: CMD_ZADD, Arity: -3, Flags: FlagWrite | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"sortedset"},
+			Group: "sorted-set", Summary: "Adds one or more members to a sorted set.",
+			Handler: (*Server).zAdd,
+		},
+		&CommandSpec{
+			Name: CMD_ZCARD, Arity: 2, Flags: FlagReadonly | FlagFast,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"sortedset"},
+			Group: "sorted-set", Summary: "Returns the number of members in a sorted set.",
+			Handler: (*Server).zCard,
+		},
+		&CommandSpec{
+			Name: CMD_ZMEMBERS, Arity: 2, Flags: FlagReadonly,
+			FirstKey: 1, LastKey: 1, Step: 1, Categories: []string{"sortedset"},
+			Group: "sorted-set", Summary: "Returns all members of a sorted set in order.",
+			Handler: (*Server).zMembers,
+		},
+	)
+}
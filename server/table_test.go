@@ -0,0 +1,42 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCommandSpecs(t *testing.T) {
+	tests := []struct {
+		command string
+		args    []string
+		arity   bool
+		keys    []string
+	}{
+		{"GET", []string{"key"}, true, []string{"key"}},
+		{"GET", nil, false, nil},
+		{"GET", []string{"key", "extra"}, false, nil},
+		{"SET", []string{"key", "value", "NX"}, true, []string{"key"}},
+		{"MSET", []string{"a", "1", "b", "2"}, true, []string{"a", "b"}},
+		{"MGET", []string{"a", "b", "c"}, true, []string{"a", "b", "c"}},
+		{"BITOP", []string{"AND", "dest", "a", "b"}, true, []string{"dest", "a", "b"}},
+		{"LCS", []string{"a", "b", "LEN"}, true, []string{"a", "b"}},
+		{"KEYS", []string{"*"}, true, nil},
+		{"COMMAND", nil, true, nil},
+	}
+	for _, test := range tests {
+		spec, ok := LookupCommand(test.command)
+		if !ok {
+			t.Errorf("%s: got no command, wanted one", test.command)
+			continue
+		}
+		if got := spec.checkArity(len(test.args) + 1); got != test.arity {
+			t.Errorf("%s %q: got arity check %t, wanted %t", test.command, test.args, got, test.arity)
+		}
+		if !test.arity {
+			continue
+		}
+		if got := spec.Keys(test.args); !reflect.DeepEqual(got, test.keys) {
+			t.Errorf("%s %q: got %q, wanted %q", test.command, test.args, got, test.keys)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Settings of a TLS listener. The certificate, key and CA files are
+// watched for changes and reloaded on the next handshake, so renewed
+// certificates are picked up without a restart.
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// CA certificates used to verify client certificates
+	CAFile string
+	// Client certificate verification, one of "no", "optional"
+	// or "yes"
+	AuthClients string
+	// Comma separated names of the allowed TLS 1.2 cipher suites,
+	// TLS 1.3 suites are not configurable
+	Ciphers string
+	// Minimum protocol version, "TLSv1.2" or "TLSv1.3"
+	MinVersion string
+	// When set to "CN", clients presenting a certificate are
+	// authenticated as the ACL user named by its common name
+	AuthClientsUser string
+}
+
+var ErrNoCertificate = errors.New("tls requires both a certificate and a key file")
+
+// Loads the certificate files and reloads them when they change.
+type certReloader struct {
+	mutex    *sync.Mutex
+	config   *TLSConfig
+	cert     *tls.Certificate
+	caPool   *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Reload the certificate and CA files if any of them has been
+// modified since they were last loaded.
+func (r *certReloader) reload() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	files := []string{r.config.CertFile, r.config.KeyFile, r.config.CAFile}
+	changed := r.cert == nil
+	for _, file := range files {
+		if file != "" && !modTime(file).Equal(r.modTimes[file]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return err
+	}
+	var pool *x509.CertPool
+	if r.config.CAFile != "" {
+		ca, err := os.ReadFile(r.config.CAFile)
+		if err != nil {
+			return err
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return fmt.Errorf("no certificates found in %s", r.config.CAFile)
+		}
+	}
+
+	r.cert, r.caPool = &cert, pool
+	for _, file := range files {
+		if file != "" {
+			r.modTimes[file] = modTime(file)
+		}
+	}
+	return nil
+}
+
+func (r *certReloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.cert, r.caPool
+}
+
+// Build the configuration used by the TLS listener.
+func (c *TLSConfig) Build() (*tls.Config, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, ErrNoCertificate
+	}
+
+	base := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch strings.ToUpper(c.MinVersion) {
+	case "", "TLSV1.2":
+	case "TLSV1.3":
+		base.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported tls version '%s'", c.MinVersion)
+	}
+
+	switch strings.ToLower(c.AuthClients) {
+	case "", "no":
+		base.ClientAuth = tls.NoClientCert
+	case "optional":
+		base.ClientAuth = tls.VerifyClientCertIfGiven
+	case "yes":
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("invalid tls client authentication '%s'", c.AuthClients)
+	}
+	if base.ClientAuth != tls.NoClientCert && c.CAFile == "" {
+		return nil, errors.New("tls client authentication requires a CA file")
+	}
+
+	if c.Ciphers != "" {
+		suites := make(map[string]uint16)
+		for _, suite := range tls.CipherSuites() {
+			suites[suite.Name] = suite.ID
+		}
+		for _, name := range strings.Split(c.Ciphers, ",") {
+			id, ok := suites[strings.TrimSpace(name)]
+			if !ok {
+				return nil, fmt.Errorf("unknown tls cipher suite '%s'", name)
+			}
+			base.CipherSuites = append(base.CipherSuites, id)
+		}
+	}
+
+	switch strings.ToUpper(c.AuthClientsUser) {
+	case "", "OFF", "CN":
+	default:
+		return nil, fmt.Errorf("invalid tls client user source '%s'", c.AuthClientsUser)
+	}
+
+	reloader := &certReloader{
+		mutex: &sync.Mutex{}, config: c, modTimes: make(map[string]time.Time),
+	}
+	if err := reloader.reload(); err != nil {
+		return nil, err
+	}
+
+	// Every handshake gets a configuration with the latest certificates
+	config := base.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		if err := reloader.reload(); err != nil {
+			logf(LogWarning, "unable to reload tls certificates, keeping the previous ones: %v", err)
+		}
+		cert, pool := reloader.current()
+		config := base.Clone()
+		config.Certificates = []tls.Certificate{*cert}
+		config.ClientCAs = pool
+		return config, nil
+	}
+	return config, nil
+}
+
+// Returns the common name of the verified client certificate of a
+// TLS connection, if there is one.
+func peerCommonName(conn *tls.Conn) (string, bool) {
+	state := conn.ConnectionState()
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	name := state.VerifiedChains[0][0].Subject.CommonName
+	return name, name != ""
+}
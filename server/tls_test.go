@@ -0,0 +1,160 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// Create a certificate signed by the parent, or a self signed CA
+// certificate if the parent is nil.
+func createTestCert(t *testing.T, name string, serial int64, parent *testCert) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, certFile, keyFile string) {
+	t.Helper()
+	keyDER, _ := x509.MarshalECPrivateKey(c.key)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	if keyFile != "" {
+		if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+			t.Fatalf("got %s, wanted nil", err.Error())
+		}
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{c.cert.Raw}, PrivateKey: c.key}
+}
+
+func TestTLSClientCertificateUser(t *testing.T) {
+	dir := t.TempDir()
+	ca := createTestCert(t, "ca", 1, nil)
+	ca.write(t, filepath.Join(dir, "ca.crt"), "")
+	createTestCert(t, "localhost", 2, ca).write(t, filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"))
+	client := createTestCert(t, "alice", 3, ca)
+
+	server := NewServer("")
+	server.AddListener(ListenerConfig{Network: "tcp", Addr: "127.0.0.1:0", TLS: &TLSConfig{
+		CertFile:        filepath.Join(dir, "server.crt"),
+		KeyFile:         filepath.Join(dir, "server.key"),
+		CAFile:          filepath.Join(dir, "ca.crt"),
+		AuthClients:     "yes",
+		AuthClientsUser: "CN",
+	}})
+	server.ACL.SetUser("alice", []string{"on", "allkeys", "allcommands"})
+	server.ACL.SetUser("default", []string{"off"})
+	if err := server.Listen(); err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	go server.Serve()
+	defer server.Shutdown(context.Background())
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	dial := func(certs []tls.Certificate) (*tls.Conn, error) {
+		return tls.Dial("tcp", server.Listeners[0].Addr().String(), &tls.Config{
+			RootCAs: pool, Certificates: certs,
+		})
+	}
+
+	conn, err := dial([]tls.Certificate{client.tlsCertificate()})
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte("ACL WHOAMI\r\n"))
+
+	reader := bufio.NewReader(conn)
+	reader.ReadString('\n')
+	got, _ := reader.ReadString('\n')
+	want := "alice\r\n"
+	if got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+
+	// Without a client certificate the handshake is rejected
+	if conn, err := dial(nil); err == nil {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Read(make([]byte, 1)); err == nil {
+			t.Errorf("got nil, wanted handshake error")
+		}
+		conn.Close()
+	}
+}
+
+func TestTLSCertificateReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	ca := createTestCert(t, "ca", 1, nil)
+	createTestCert(t, "localhost", 2, ca).write(t, certFile, keyFile)
+
+	config := &TLSConfig{CertFile: certFile, KeyFile: keyFile}
+	tlsConfig, err := config.Build()
+	if err != nil {
+		t.Fatalf("got %s, wanted nil", err.Error())
+	}
+	serial := func() int64 {
+		handshake, _ := tlsConfig.GetConfigForClient(&tls.ClientHelloInfo{})
+		cert, _ := x509.ParseCertificate(handshake.Certificates[0].Certificate[0])
+		return cert.SerialNumber.Int64()
+	}
+
+	if got := serial(); got != 2 {
+		t.Errorf("got %d, wanted %d", got, 2)
+	}
+	createTestCert(t, "localhost", 5, ca).write(t, certFile, keyFile)
+	future := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, future, future)
+	if got := serial(); got != 5 {
+		t.Errorf("got %d, wanted %d", got, 5)
+	}
+}
@@ -1,42 +1,40 @@
 package server
 
 import (
-	"fmt"
-	"strconv"
+	"errors"
+
+	"github.com/Devansh3712/tandb/store"
 )
 
 func (s *Server) zAdd(cmd Command) {
-	if len(cmd.Args) < 2 {
-		cmd.error(ErrNotEnoughArgs)
-		return
+	added := 0
+	for _, member := range cmd.Args[1:] {
+		ok, err := s.DB.ZAdd(cmd.Args[0], member)
+		if err != nil {
+			cmd.error(err)
+			return
+		}
+		if ok {
+			added++
+		}
 	}
-	s.DB.ZAdd(cmd.Args[0], cmd.Args[1])
+	cmd.integer(added)
 }
 
 func (s *Server) zMembers(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
 	elements, err := s.DB.ZMembers(cmd.Args[0])
-	if err != nil {
+	if err != nil && !errors.Is(err, store.ErrSetNotExists) {
 		cmd.error(err)
 		return
 	}
-	for index, element := range elements {
-		cmd.write(fmt.Sprintf("%d) %s", index+1, element))
-	}
+	cmd.array(elements)
 }
 
 func (s *Server) zCard(cmd Command) {
-	if len(cmd.Args) < 1 {
-		cmd.error(ErrNotEnoughArgs)
-		return
-	}
 	size, err := s.DB.ZCard(cmd.Args[0])
-	if err != nil {
+	if err != nil && !errors.Is(err, store.ErrSetNotExists) {
 		cmd.error(err)
 		return
 	}
-	cmd.write(strconv.Itoa(size))
+	cmd.integer(size)
 }
@@ -1,7 +1,8 @@
 // Unsorted set implementation using map. It stores the elements
 // as a key and an empty struct as value, as an empty struct takes
 // 0 bytes of memory. The methods are concurrency safe, using a
-// read-write mutex.
+// read-write mutex. Elements are binary safe, as a Go string may
+// hold arbitrary bytes.
 
 package set
 
@@ -58,6 +59,21 @@ func (s *Set) Remove(element string) error {
 	return nil
 }
 
+// Returns a deep copy of the set, with its own mutex.
+func (s *Set) Clone() Set {
+	s.Mutex.RLock()
+	defer s.Mutex.RUnlock()
+
+	clone := Set{
+		Mutex:    &sync.RWMutex{},
+		Elements: make(map[string]struct{}, len(s.Elements)),
+	}
+	for element := range s.Elements {
+		clone.Elements[element] = struct{}{}
+	}
+	return clone
+}
+
 func (s1 *Set) Union(s2 Set) Set {
 	elements := NewSet()
 	for element := range s1.Elements {
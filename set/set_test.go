@@ -45,3 +45,36 @@ func TestRemove(t *testing.T) {
 		t.Errorf("got %s, wanted nil", err.Error())
 	}
 }
+
+func TestBinaryElements(t *testing.T) {
+	set := NewSet()
+	set.Add("a\x00b")
+	set.Add("a\x00c")
+	set.Add("\xff\r\n")
+
+	got := set.Size()
+	want := 3
+	if got != want {
+		t.Errorf("got %d, wanted %d", got, want)
+	}
+	if !set.Exists("a\x00b") || set.Exists("a") {
+		t.Errorf("got wrong membership for binary elements")
+	}
+}
+
+func TestClone(t *testing.T) {
+	set := createTestSet()
+	clone := set.Clone()
+	clone.Add("world")
+	set.Remove("hello")
+
+	if got, want := set.Size(), len(members)-1; got != want {
+		t.Errorf("got %d, wanted %d", got, want)
+	}
+	if got, want := clone.Size(), len(members)+1; got != want {
+		t.Errorf("got %d, wanted %d", got, want)
+	}
+	if !clone.Exists("hello") || set.Exists("world") {
+		t.Errorf("got a clone sharing elements with its set")
+	}
+}
@@ -0,0 +1,418 @@
+package store
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// Bits of a bitmap are numbered from the most significant bit of the
+// first byte, so bit 0 is the 0x80 bit of byte 0.
+
+// Count the set bits of a slice, eight bytes at a time.
+func popcount(b []byte) int {
+	n := 0
+	for len(b) >= 8 {
+		n += bits.OnesCount64(binary.LittleEndian.Uint64(b))
+		b = b[8:]
+	}
+	for _, c := range b {
+		n += bits.OnesCount8(c)
+	}
+	return n
+}
+
+// Clamp an inclusive range to a length, where negative offsets count
+// from the end. Returns false if the range is empty.
+func clampRange(start, end, length int) (int, int, bool) {
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if end < 0 {
+		end = length + end
+	}
+	end = min(end, length-1)
+	if length == 0 || start > end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// Extend a value with zero bytes to at least the given length, in
+// place when its capacity allows.
+func extend(data []byte, length int) []byte {
+	if length <= len(data) {
+		return data
+	}
+	return append(data, make([]byte, length-len(data))...)
+}
+
+// An inclusive range of a bitmap, in bytes unless Bits is set.
+// Negative offsets count from the end of the bitmap.
+type BitRange struct {
+	Start, End int
+	Bits       bool
+}
+
+// The whole bitmap.
+var FullRange = BitRange{Start: 0, End: -1}
+
+// Convert a range to an inclusive range of bits within a value of a
+// length in bytes. Returns false if the range is empty.
+func (r BitRange) bits(length int) (int, int, bool) {
+	if r.Bits {
+		return clampRange(r.Start, r.End, length*8)
+	}
+	start, end, ok := clampRange(r.Start, r.End, length)
+	return start * 8, end*8 + 7, ok
+}
+
+// Returns the bit of the value of a key at an offset. Bits beyond the
+// end of the value are 0.
+func (s *Store) GetBit(key string, offset int) (int, error) {
+	bit := 0
+	err := s.readString(key, func(data []byte) {
+		if offset/8 < len(data) {
+			bit = int(data[offset/8]>>(7-offset%8)) & 1
+		}
+	})
+	if err == ErrKeyNotExists {
+		return 0, nil
+	}
+	return bit, err
+}
+
+// Set or clear the bit of the value of a key at an offset, growing the
+// value if needed, and return the previous bit.
+func (s *Store) SetBit(key string, offset int, bit int) (int, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, _, err := s.stringRecord(key)
+	if err != nil {
+		return 0, err
+	}
+	data := extend(value.Bytes(), offset/8+1)
+	mask := byte(0x80) >> (offset % 8)
+	previous := 0
+	if data[offset/8]&mask != 0 {
+		previous = 1
+	}
+	if bit == 1 {
+		data[offset/8] |= mask
+	} else {
+		data[offset/8] &^= mask
+	}
+	value.Data, value.Int, value.Encoding = data, 0, EncodingRaw
+	s.putRecord(key, value)
+	return previous, nil
+}
+
+// Count the set bits of the value of a key within a range.
+func (s *Store) BitCount(key string, r BitRange) (int, error) {
+	count := 0
+	err := s.readString(key, func(data []byte) {
+		count = bitCount(data, r)
+	})
+	if err == ErrKeyNotExists {
+		return 0, nil
+	}
+	return count, err
+}
+
+// Count the set bits of a value within a range.
+func bitCount(value []byte, r BitRange) int {
+	start, end, ok := r.bits(len(value))
+	if !ok {
+		return 0
+	}
+
+	first, last := start/8, end/8
+	firstMask := byte(0xff) >> (start % 8)
+	lastMask := byte(0xff) << (7 - end%8)
+	if first == last {
+		return bits.OnesCount8(value[first] & firstMask & lastMask)
+	}
+	return bits.OnesCount8(value[first]&firstMask) +
+		popcount(value[first+1:last]) +
+		bits.OnesCount8(value[last]&lastMask)
+}
+
+// Returns the offset of the first bit set to the given bit within a
+// range of the value of a key, or -1 if there is none.
+//
+// Looking for a clear bit without an explicit end of the range finds
+// the first bit after the end of the value, as if it was padded with
+// zeros.
+func (s *Store) BitPos(key string, bit int, r BitRange, endGiven bool) (int, error) {
+	pos := 0
+	err := s.readString(key, func(data []byte) {
+		pos = bitPos(data, bit, r, endGiven)
+	})
+	if err == ErrKeyNotExists {
+		if bit == 1 {
+			return -1, nil
+		}
+		return 0, nil
+	}
+	return pos, err
+}
+
+// Returns the offset of the first bit set to the given bit within a
+// range of a value, as BitPos.
+func bitPos(value []byte, bit int, r BitRange, endGiven bool) int {
+	start, end, ok := r.bits(len(value))
+	if !ok {
+		return -1
+	}
+
+	// Bytes made only of the other bit are skipped whole
+	skip := byte(0)
+	if bit == 0 {
+		skip = 0xff
+	}
+	for offset := start; offset <= end; {
+		if offset%8 == 0 && offset+7 <= end && value[offset/8] == skip {
+			offset += 8
+			continue
+		}
+		if int(value[offset/8]>>(7-offset%8))&1 == bit {
+			return offset
+		}
+		offset++
+	}
+	if bit == 0 && !endGiven {
+		return end + 1
+	}
+	return -1
+}
+
+// Bitwise operations of BitOp.
+const (
+	BitAnd = iota
+	BitOr
+	BitXor
+	BitNot
+)
+
+// Store the result of a bitwise operation between the values of keys
+// in the destination key, and return its length. Missing keys and the
+// ends of shorter values are treated as zeros. BitNot takes one key.
+// An empty result deletes the destination key.
+func (s *Store) BitOp(op int, destination string, keys []string) (int, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	sources := make([][]byte, len(keys))
+	length := 0
+	for i, key := range keys {
+		value, _, err := s.stringRecord(key)
+		if err != nil {
+			return 0, err
+		}
+		sources[i] = value.Bytes()
+		length = max(length, len(sources[i]))
+	}
+	if length == 0 {
+		s.deleteRecord(destination)
+		return 0, nil
+	}
+
+	result := make([]byte, length)
+	copy(result, sources[0])
+	for _, source := range sources[1:] {
+		for i := range result {
+			var b byte
+			if i < len(source) {
+				b = source[i]
+			}
+			switch op {
+			case BitAnd:
+				result[i] &= b
+			case BitOr:
+				result[i] |= b
+			case BitXor:
+				result[i] ^= b
+			}
+		}
+	}
+	if op == BitNot {
+		for i := range result {
+			result[i] = ^result[i]
+		}
+	}
+	s.putRecord(destination, Value{Data: result})
+	return length, nil
+}
+
+// Kinds of BitField operations.
+const (
+	BitFieldGet = iota
+	BitFieldSet
+	BitFieldIncrBy
+)
+
+// Behaviours of BitField writes which overflow their field.
+const (
+	// Wrap around, modulo the range of the field
+	OverflowWrap = iota
+	// Saturate to the minimum or maximum value of the field
+	OverflowSat
+	// Leave the field unchanged and return nil
+	OverflowFail
+)
+
+// A BitField operation on a signed or unsigned integer field of up to
+// 64 bits, or 63 bits when unsigned.
+type BitFieldOp struct {
+	Kind     int
+	Signed   bool
+	Bits     int
+	Offset   int
+	Value    int64
+	Overflow int
+}
+
+// Read an unsigned field. Bits beyond the end of the data are 0.
+func readField(data []byte, offset, width int) uint64 {
+	var n uint64
+	for i := offset; i < offset+width; i++ {
+		n <<= 1
+		if i/8 < len(data) {
+			n |= uint64(data[i/8]>>(7-i%8)) & 1
+		}
+	}
+	return n
+}
+
+// Write an unsigned field. The data must be long enough.
+func writeField(data []byte, offset, width int, n uint64) {
+	for i := offset + width - 1; i >= offset; i-- {
+		mask := byte(0x80) >> (i % 8)
+		if n&1 == 1 {
+			data[i/8] |= mask
+		} else {
+			data[i/8] &^= mask
+		}
+		n >>= 1
+	}
+}
+
+// Read a field as an int64, extending the sign of signed fields.
+func (op BitFieldOp) read(data []byte) int64 {
+	n := readField(data, op.Offset, op.Bits)
+	if op.Signed && op.Bits < 64 && n>>(op.Bits-1) == 1 {
+		n |= math.MaxUint64 << op.Bits
+	}
+	return int64(n)
+}
+
+// Add an increment to the value of a field according to the overflow
+// behaviour. Returns false if the operation fails.
+func (op BitFieldOp) add(value, incr int64) (int64, bool) {
+	if op.Signed {
+		fieldMax := int64(math.MaxInt64)
+		if op.Bits < 64 {
+			fieldMax = 1<<(op.Bits-1) - 1
+		}
+		fieldMin := -fieldMax - 1
+		var overflow, underflow bool
+		if incr > 0 {
+			overflow = value > fieldMax-incr
+		} else {
+			underflow = value < fieldMin-incr
+		}
+		overflow = overflow || value > fieldMax
+		underflow = underflow || value < fieldMin
+		if !overflow && !underflow {
+			return value + incr, true
+		}
+		switch op.Overflow {
+		case OverflowSat:
+			if overflow {
+				return fieldMax, true
+			}
+			return fieldMin, true
+		case OverflowFail:
+			return 0, false
+		}
+		// Keep the low bits and extend the sign of the result
+		n := uint64(value) + uint64(incr)
+		if op.Bits < 64 {
+			shift := 64 - op.Bits
+			n = uint64(int64(n<<shift) >> shift)
+		}
+		return int64(n), true
+	}
+
+	// Unsigned fields have at most 63 bits, so the value and the
+	// maximum both fit in an int64
+	fieldMax := int64(1)<<op.Bits - 1
+	result, carry := bits.Add64(uint64(value), uint64(incr), 0)
+	overflow := uint64(value) > uint64(fieldMax) ||
+		(incr > 0 && (carry == 1 || result > uint64(fieldMax)))
+	underflow := incr < 0 && uint64(-incr) > uint64(value)
+	if !overflow && !underflow {
+		return int64(result), true
+	}
+	switch op.Overflow {
+	case OverflowSat:
+		if overflow && !underflow {
+			return fieldMax, true
+		}
+		return 0, true
+	case OverflowFail:
+		return 0, false
+	}
+	return int64(result & uint64(fieldMax)), true
+}
+
+// Run BitField operations on the value of a key in a single critical
+// section, growing the value when fields are written beyond its end.
+// Returns the result of each operation, nil when an operation fails
+// because of an overflow. SET returns the previous value of the field
+// and INCRBY the new one.
+func (s *Store) BitField(key string, ops []BitFieldOp) ([]*int64, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, _, err := s.stringRecord(key)
+	if err != nil {
+		return nil, err
+	}
+	data := value.Bytes()
+	results := make([]*int64, len(ops))
+	written := false
+	for i, op := range ops {
+		current := op.read(data)
+		if op.Kind == BitFieldGet {
+			results[i] = &current
+			continue
+		}
+
+		var n int64
+		var ok bool
+		if op.Kind == BitFieldSet {
+			n, ok = op.add(op.Value, 0)
+		} else {
+			n, ok = op.add(current, op.Value)
+		}
+		if !ok {
+			continue
+		}
+		data = extend(data, (op.Offset+op.Bits+7)/8)
+		written = true
+		writeField(data, op.Offset, op.Bits, uint64(n))
+		if op.Kind == BitFieldSet {
+			results[i] = &current
+		} else {
+			results[i] = &n
+		}
+	}
+
+	if written {
+		value.Data, value.Int, value.Encoding = data, 0, EncodingRaw
+		s.putRecord(key, value)
+	}
+	return results, nil
+}
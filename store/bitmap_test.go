@@ -0,0 +1,174 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBits(t *testing.T) {
+	store := NewStore()
+	for _, offset := range []int{1, 2, 7, 8, 100} {
+		if previous, _ := store.SetBit("bits", offset, 1); previous != 0 {
+			t.Errorf("%d: got %d, wanted %d", offset, previous, 0)
+		}
+	}
+	if got, _ := store.Get("bits"); len(got) != 13 || got[0] != 0x61 || got[1] != 0x80 {
+		t.Errorf("got %x, wanted 6180 followed by 11 bytes", got)
+	}
+	if bit, _ := store.GetBit("bits", 7); bit != 1 {
+		t.Errorf("got %d, wanted %d", bit, 1)
+	}
+	if bit, _ := store.GetBit("bits", 1000); bit != 0 {
+		t.Errorf("got %d, wanted %d", bit, 0)
+	}
+
+	counts := []struct {
+		r    BitRange
+		want int
+	}{
+		{FullRange, 5},
+		{BitRange{Start: 0, End: 0}, 3},
+		{BitRange{Start: 2, End: 8, Bits: true}, 3},
+		{BitRange{Start: -1, End: -1}, 1},
+		{BitRange{Start: 5, End: 2}, 0},
+	}
+	for _, c := range counts {
+		if got, _ := store.BitCount("bits", c.r); got != c.want {
+			t.Errorf("%+v: got %d, wanted %d", c.r, got, c.want)
+		}
+	}
+
+	store.Set("ones", []byte{0xff, 0xff, 0x00})
+	positions := []struct {
+		bit      int
+		r        BitRange
+		endGiven bool
+		want     int
+	}{
+		{1, FullRange, false, 0},
+		{0, FullRange, false, 16},
+		{0, BitRange{Start: 0, End: 1}, true, -1},
+		{0, BitRange{Start: 0, End: 1}, false, 16},
+		{1, BitRange{Start: 2, End: -1}, false, -1},
+		{1, BitRange{Start: 3, End: 9, Bits: true}, true, 3},
+	}
+	for _, p := range positions {
+		if got, _ := store.BitPos("ones", p.bit, p.r, p.endGiven); got != p.want {
+			t.Errorf("%d %+v: got %d, wanted %d", p.bit, p.r, got, p.want)
+		}
+	}
+	if got, _ := store.BitPos("missing", 0, FullRange, false); got != 0 {
+		t.Errorf("got %d, wanted %d", got, 0)
+	}
+}
+
+func TestBitsInPlace(t *testing.T) {
+	store := NewStore()
+	store.SetBit("bits", 8*63, 1)
+	data := store.Records["bits"].Data
+	before, _ := store.Get("bits")
+
+	// Writes within the value modify it in place, and values handed
+	// to readers are copies
+	store.SetBit("bits", 0, 1)
+	store.BitField("bits", []BitFieldOp{{Kind: BitFieldSet, Bits: 8, Offset: 8, Value: 0xff}})
+	if &store.Records["bits"].Data[0] != &data[0] {
+		t.Errorf("got a new array, wanted the value to be modified in place")
+	}
+	if before[0] != 0 || before[1] != 0 {
+		t.Errorf("got %x, wanted the copy to be unchanged", before[:2])
+	}
+	if got, _ := store.Get("bits"); got[0] != 0x80 || got[1] != 0xff {
+		t.Errorf("got %x, wanted %x", got[:2], []byte{0x80, 0xff})
+	}
+
+	// Copies keep their own array
+	store.Copy("bits", "copy", false)
+	store.SetBit("copy", 0, 0)
+	if bit, _ := store.GetBit("bits", 0); bit != 1 {
+		t.Errorf("got %d, wanted %d", bit, 1)
+	}
+}
+
+func TestBitOp(t *testing.T) {
+	store := NewStore()
+	store.Set("a", []byte{0xf0, 0x0f})
+	store.Set("b", []byte{0xff})
+
+	ops := []struct {
+		op   int
+		keys []string
+		want []byte
+	}{
+		{BitAnd, []string{"a", "b"}, []byte{0xf0, 0x00}},
+		{BitOr, []string{"a", "b", "missing"}, []byte{0xff, 0x0f}},
+		{BitXor, []string{"a", "b"}, []byte{0x0f, 0x0f}},
+		{BitNot, []string{"a"}, []byte{0x0f, 0xf0}},
+	}
+	for _, op := range ops {
+		if n, err := store.BitOp(op.op, "dest", op.keys); err != nil || n != len(op.want) {
+			t.Errorf("%d: got %d, %v, wanted %d, nil", op.op, n, err, len(op.want))
+		}
+		if got, _ := store.Get("dest"); string(got) != string(op.want) {
+			t.Errorf("%d: got %x, wanted %x", op.op, got, op.want)
+		}
+	}
+	// The sources are not modified
+	if got, _ := store.Get("a"); string(got) != "\xf0\x0f" {
+		t.Errorf("got %x, wanted %x", got, "\xf0\x0f")
+	}
+	if n, _ := store.BitOp(BitAnd, "dest", []string{"missing"}); n != 0 || store.Exists("dest") {
+		t.Errorf("got %d, wanted %d and no key", n, 0)
+	}
+}
+
+func TestBitField(t *testing.T) {
+	store := NewStore()
+	u8 := BitFieldOp{Bits: 8, Offset: 0}
+	i8 := BitFieldOp{Signed: true, Bits: 8, Offset: 8}
+	with := func(op BitFieldOp, kind int, value int64, overflow int) BitFieldOp {
+		op.Kind, op.Value, op.Overflow = kind, value, overflow
+		return op
+	}
+
+	tests := []struct {
+		op   BitFieldOp
+		want string
+	}{
+		{with(u8, BitFieldSet, 255, OverflowWrap), "0"},
+		{with(u8, BitFieldIncrBy, 10, OverflowWrap), "9"},
+		{with(u8, BitFieldIncrBy, 300, OverflowSat), "255"},
+		{with(u8, BitFieldIncrBy, -300, OverflowSat), "0"},
+		{with(u8, BitFieldIncrBy, -1, OverflowFail), "<nil>"},
+		{with(u8, BitFieldSet, -1, OverflowWrap), "0"},
+		{with(u8, BitFieldGet, 0, OverflowWrap), "255"},
+		{with(i8, BitFieldSet, 127, OverflowWrap), "0"},
+		{with(i8, BitFieldIncrBy, 1, OverflowWrap), "-128"},
+		{with(i8, BitFieldIncrBy, -1, OverflowSat), "-128"},
+		{with(i8, BitFieldIncrBy, 1000, OverflowSat), "127"},
+		{with(i8, BitFieldSet, 200, OverflowFail), "<nil>"},
+		{with(i8, BitFieldGet, 0, OverflowWrap), "127"},
+		{with(BitFieldOp{Signed: true, Bits: 64, Offset: 100}, BitFieldIncrBy, -1, OverflowWrap), "-1"},
+	}
+	for _, test := range tests {
+		results, err := store.BitField("field", []BitFieldOp{test.op})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := "<nil>"
+		if results[0] != nil {
+			got = fmt.Sprint(*results[0])
+		}
+		if got != test.want {
+			t.Errorf("%+v: got %s, wanted %s", test.op, got, test.want)
+		}
+	}
+	if got, _ := store.StrLen("field"); got != 21 {
+		t.Errorf("got %d, wanted %d", got, 21)
+	}
+
+	// Reading does not create the key
+	if _, err := store.BitField("missing", []BitFieldOp{u8}); err != nil || store.Exists("missing") {
+		t.Errorf("got %v, wanted nil and no key", err)
+	}
+}
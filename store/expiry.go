@@ -0,0 +1,232 @@
+package store
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// Number of due keys removed by the sweeper before it checks its
+// time budget and lets other clients take the lock.
+const sweepBatchSize = 64
+
+type expiryItem struct {
+	key string
+	at  int64
+	// Position of the item in the heap
+	index int
+}
+
+// expiryHeap is a min-heap of keys ordered by their expiration time.
+type expiryHeap []*expiryItem
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].at < h[j].at }
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x any) {
+	item := x.(*expiryItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return item
+}
+
+// expiryIndex holds the expiration time of every key which has one,
+// so the sweeper only visits keys which are due. It is guarded by
+// the mutex of the store.
+type expiryIndex struct {
+	heap  expiryHeap
+	items map[string]*expiryItem
+}
+
+func newExpiryIndex() *expiryIndex {
+	return &expiryIndex{items: make(map[string]*expiryItem)}
+}
+
+// Set the expiration time of a key, in Unix milliseconds. A time of
+// 0 removes the key from the index.
+func (x *expiryIndex) set(key string, at int64) {
+	if at == 0 {
+		x.remove(key)
+		return
+	}
+	if item, ok := x.items[key]; ok {
+		item.at = at
+		heap.Fix(&x.heap, item.index)
+		return
+	}
+	item := &expiryItem{key: key, at: at}
+	heap.Push(&x.heap, item)
+	x.items[key] = item
+}
+
+func (x *expiryIndex) remove(key string) {
+	if item, ok := x.items[key]; ok {
+		heap.Remove(&x.heap, item.index)
+		delete(x.items, key)
+	}
+}
+
+// Returns the key which expires first.
+func (x *expiryIndex) peek() (string, int64, bool) {
+	if len(x.heap) == 0 {
+		return "", 0, false
+	}
+	return x.heap[0].key, x.heap[0].at, true
+}
+
+func (x *expiryIndex) len() int {
+	return len(x.heap)
+}
+
+// Store a record and index its expiration time. Must be called
+// with the mutex locked.
+func (s *Store) putRecord(key string, value Value) {
+	if previous, ok := s.Records[key]; ok {
+		s.counts[previous.Type]--
+	}
+	s.Records[key] = value
+	s.counts[value.Type]++
+	s.expires.set(key, value.ExpireAt)
+}
+
+// Remove a record along with its expiration time. Must be called
+// with the mutex locked.
+func (s *Store) deleteRecord(key string) {
+	if previous, ok := s.Records[key]; ok {
+		s.counts[previous.Type]--
+		delete(s.Records, key)
+	}
+	s.expires.remove(key)
+}
+
+// Remove a record if it has expired, and return whether it was
+// removed. Must be called with the mutex locked.
+func (s *Store) expireIfNeeded(key string) bool {
+	value, ok := s.Records[key]
+	if !ok || !value.expired() {
+		return false
+	}
+	s.deleteRecord(key)
+	s.Stats.Expired.Add(1)
+	return true
+}
+
+// Fetch a record which has not expired, leaving an expired record
+// for the sweeper or a later write. Must be called with the mutex held.
+func (s *Store) liveRecord(key string) (Value, bool) {
+	value, ok := s.Records[key]
+	if !ok || value.expired() {
+		return Value{}, false
+	}
+	return value, true
+}
+
+// Fetch a record which has not expired and holds a value of a type.
+// Returns ErrWrongType if the key holds another type. Must be called
+// with the mutex held.
+func (s *Store) typedRecord(key string, valueType int) (Value, bool, error) {
+	value, ok := s.liveRecord(key)
+	if ok && value.Type != valueType {
+		return Value{}, false, ErrWrongType
+	}
+	return value, ok, nil
+}
+
+// Fetch a record, treating an expired record as missing and
+// removing it. Must be called without holding the mutex.
+func (s *Store) lookup(key string) (Value, bool) {
+	s.Mutex.RLock()
+	value, ok := s.Records[key]
+	s.Mutex.RUnlock()
+
+	if ok && value.expired() {
+		s.Mutex.Lock()
+		s.expireIfNeeded(key)
+		s.Mutex.Unlock()
+		return Value{}, false
+	}
+	return value, ok
+}
+
+// Run a function on the data of a string key while holding the read
+// lock, as writers modify values in place, so the data must not be
+// kept after it returns. Returns ErrKeyNotExists without running the
+// function if the key does not exist, removing it if it has expired.
+func (s *Store) readString(key string, read func(data []byte)) error {
+	s.Mutex.RLock()
+	value, ok := s.Records[key]
+	expired := ok && value.expired()
+	var err error
+	switch {
+	case !ok || expired:
+		err = ErrKeyNotExists
+	case value.Type != TypeString:
+		err = ErrWrongType
+	default:
+		read(value.Bytes())
+	}
+	s.Mutex.RUnlock()
+
+	if expired {
+		s.Mutex.Lock()
+		s.expireIfNeeded(key)
+		s.Mutex.Unlock()
+	}
+	return err
+}
+
+// Run a background job which removes expired keys every interval.
+// Each cycle only visits keys which are due, taken from the expiry
+// index, and stops once it has run for the budget so that a large
+// number of keys expiring together cannot stall the clients. The
+// remaining keys are removed by the next cycles, or lazily when they
+// are accessed. The job stops when the context is cancelled.
+func (s *Store) CheckTTL(ctx context.Context, interval, budget time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		for s.sweep(sweepBatchSize) && time.Since(start) < budget {
+		}
+		s.Stats.Sweeps.Add(1)
+		s.Stats.SweepTime.Add(int64(time.Since(start)))
+	}
+}
+
+// Remove up to limit keys which are due. Returns false once no
+// due key is left.
+func (s *Store) sweep(limit int) bool {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	now := time.Now().UnixMilli()
+	for i := 0; i < limit; i++ {
+		key, at, ok := s.expires.peek()
+		if !ok || at > now {
+			return false
+		}
+		s.deleteRecord(key)
+		s.Stats.Expired.Add(1)
+	}
+	return true
+}
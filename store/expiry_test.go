@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLazyExpiry(t *testing.T) {
+	store := NewStore()
+	store.SetEx("expired", []byte("value"), time.Millisecond)
+	store.SetEx("later", []byte("value"), time.Hour)
+	store.Set("persistent", []byte("value"))
+	time.Sleep(5 * time.Millisecond)
+
+	if got := store.Info().Expires; got != 2 {
+		t.Errorf("got %d, wanted %d", got, 2)
+	}
+	if _, err := store.Get("expired"); err != ErrKeyNotExists {
+		t.Errorf("got %v, wanted %v", err, ErrKeyNotExists)
+	}
+	if _, ok := store.Records["expired"]; ok {
+		t.Errorf("got an expired record, wanted it to be removed")
+	}
+	if got := store.Info().Expires; got != 1 {
+		t.Errorf("got %d, wanted %d", got, 1)
+	}
+	if got := store.Stats.Expired.Load(); got != 1 {
+		t.Errorf("got %d, wanted %d", got, 1)
+	}
+
+	if !store.Persist("later") {
+		t.Errorf("got false, wanted true")
+	}
+	if got := store.Info().Expires; got != 0 {
+		t.Errorf("got %d, wanted %d", got, 0)
+	}
+}
+
+func TestSweep(t *testing.T) {
+	store := NewStore()
+	for i := 0; i < 3*sweepBatchSize; i++ {
+		store.SetEx(string(rune('a'+i)), []byte("value"), time.Millisecond)
+	}
+	store.SetEx("later", []byte("value"), time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go store.CheckTTL(ctx, time.Millisecond, time.Second)
+	defer cancel()
+
+	deadline := time.Now().Add(time.Second)
+	for store.Info().Strings > 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := store.Info().Strings; got != 1 {
+		t.Errorf("got %d, wanted %d", got, 1)
+	}
+	if got := store.Stats.Expired.Load(); got != 3*sweepBatchSize {
+		t.Errorf("got %d, wanted %d", got, 3*sweepBatchSize)
+	}
+}
@@ -1,153 +1,483 @@
 package store
 
 import (
+	"bytes"
 	"errors"
+	"strconv"
 	"time"
+
+	"github.com/Devansh3712/tandb/glob"
+	"github.com/Devansh3712/tandb/set"
+	"github.com/Devansh3712/tandb/zset"
 )
 
 var (
 	ErrKeyExists    = errors.New("the key already exists")
 	ErrKeyNotExists = errors.New("the key does not exist")
+	ErrWrongType    = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+)
+
+// Types of the values of keys.
+const (
+	TypeString = iota
+	TypeSet
+	TypeZSet
+	typeCount
 )
 
+// Names of the types, as replied by TYPE.
+var typeNames = [typeCount]string{"string", "set", "zset"}
+
+// Encodings of a value.
+const (
+	EncodingRaw = iota
+	// Integers are kept as an int64 instead of their text, so that
+	// counters are not parsed again on every increment
+	EncodingInt
+)
+
+// The value of a key along with its type and expiration time. Strings
+// are kept in Data or Int depending on their encoding, sets in Set
+// and sorted sets in ZSet.
 type Value struct {
-	Timestamp  time.Time
-	Data       []byte
-	Expiration time.Duration
+	Type     int
+	Data     []byte
+	Int      int64
+	Encoding int
+	Set      set.Set
+	ZSet     zset.ZSet
+	// Unix time in milliseconds at which the key expires,
+	// 0 if the key is persistent
+	ExpireAt int64
 }
 
-func (v *Value) expired() bool {
-	if v.Expiration == -1 {
-		return false
+// Create a value, using the integer encoding if the data is the
+// canonical text of an int64.
+func newValue(data []byte) Value {
+	if n, ok := parseCanonicalInt(data); ok {
+		return Value{Int: n, Encoding: EncodingInt}
 	}
-	now := time.Now()
-	ttl := v.Timestamp.Add(v.Expiration)
-	return now.After(ttl)
+	return Value{Data: data}
 }
 
-// Check if a key exists.
-func (s *Store) Exists(key string) bool {
-	s.Mutex.RLock()
-	defer s.Mutex.RUnlock()
+// Returns the data of a value as bytes, whatever its encoding. The
+// data is shared with the keyspace, where writers modify it in place,
+// so it must be copied before it is handed out.
+func (v *Value) Bytes() []byte {
+	if v.Encoding == EncodingInt {
+		return strconv.AppendInt(nil, v.Int, 10)
+	}
+	return v.Data
+}
+
+func (v *Value) expired() bool {
+	return v.ExpireAt != 0 && v.ExpireAt <= time.Now().UnixMilli()
+}
+
+// Conditions of ExpireAt, which can be combined.
+const (
+	// Only if the key has no expiration time
+	ExpireNX = 1 << iota
+	// Only if the key has an expiration time
+	ExpireXX
+	// Only if the new expiration time is later than the current one
+	ExpireGT
+	// Only if the new expiration time is earlier than the current one
+	ExpireLT
+)
 
-	_, ok := s.Records[key]
+// Check if a key exists, whatever the type of its value.
+func (s *Store) Exists(key string) bool {
+	_, ok := s.lookup(key)
 	return ok
 }
 
-// Store a key-value pair with an expiration time (in seconds).
-// If the value of expiration is -1, persist the record.
-//
-// Persistence refers to a key-value pair with no expiration.
-func (s *Store) SetEx(key string, value []byte, expiration time.Duration) error {
-	if s.Exists(key) {
-		return ErrKeyExists
+// Returns the name of the type of the value of a key, or "none" if
+// the key does not exist.
+func (s *Store) Type(key string) string {
+	value, ok := s.lookup(key)
+	if !ok {
+		return "none"
 	}
+	return typeNames[value.Type]
+}
+
+// Options of SetWithOptions.
+type SetOptions struct {
+	// Only set the key if it does not exist
+	NX bool
+	// Only set the key if it already exists
+	XX bool
+	// Time at which the key expires, the zero time for none
+	ExpireAt time.Time
+	// Keep the expiration time of an existing key
+	KeepTTL bool
+	// Fail with ErrWrongType if the key holds another type than a
+	// string, as its previous value is needed
+	Get bool
+}
+
+// Store a key-value pair, overwriting any existing value along
+// with its expiration time. Persistence refers to a key-value pair
+// with no expiration.
+func (s *Store) Set(key string, value []byte) {
+	s.SetWithOptions(key, value, SetOptions{})
+}
+
+// Store a key-value pair which expires after a duration, overwriting
+// any existing value.
+func (s *Store) SetEx(key string, value []byte, expiration time.Duration) {
+	s.SetWithOptions(key, value, SetOptions{ExpireAt: time.Now().Add(expiration)})
+}
+
+// Store a persistent key-value pair only if the key does not exist.
+// Returns false if the key already exists.
+func (s *Store) SetNX(key string, value []byte) bool {
+	_, _, err := s.SetWithOptions(key, value, SetOptions{NX: true})
+	return err == nil
+}
+
+// Store a key-value pair according to the options, overwriting a
+// value of any type. Returns the previous value of the key and whether
+// the key existed. The error is ErrKeyExists or ErrKeyNotExists when
+// the value is not stored because of NX or XX. A key set to expire in
+// the past is removed.
+func (s *Store) SetWithOptions(key string, value []byte, options SetOptions) ([]byte, bool, error) {
 	s.Mutex.Lock()
 	defer s.Mutex.Unlock()
 
-	s.Records[key] = Value{
-		Timestamp: time.Now(), Data: value, Expiration: expiration * time.Second,
+	s.expireIfNeeded(key)
+	previous, exists := s.Records[key]
+	if options.Get && exists && previous.Type != TypeString {
+		return nil, exists, ErrWrongType
+	}
+	if options.NX && exists {
+		// The previous value is kept, so the caller gets a copy
+		return bytes.Clone(previous.Bytes()), exists, ErrKeyExists
+	}
+	if options.XX && !exists {
+		return nil, exists, ErrKeyNotExists
 	}
-	return nil
-}
 
-// Store a persistent key-value pair.
-func (s *Store) Set(key string, value []byte) error {
-	return s.SetEx(key, value, -1)
+	record := newValue(value)
+	if options.KeepTTL && exists {
+		record.ExpireAt = previous.ExpireAt
+	} else if !options.ExpireAt.IsZero() {
+		if !options.ExpireAt.After(time.Now()) {
+			s.deleteRecord(key)
+			return previous.Bytes(), exists, nil
+		}
+		record.ExpireAt = options.ExpireAt.UnixMilli()
+	}
+	s.putRecord(key, record)
+	return previous.Bytes(), exists, nil
 }
 
-// Fetch a value of the input key.
+// Fetch a copy of the value of the input key.
 func (s *Store) Get(key string) ([]byte, error) {
-	s.Mutex.RLock()
-	defer s.Mutex.RUnlock()
+	var value []byte
+	err := s.readString(key, func(data []byte) {
+		value = bytes.Clone(data)
+	})
+	if err == ErrKeyNotExists {
+		s.Stats.Misses.Add(1)
+	} else {
+		s.Stats.Hits.Add(1)
+	}
+	return value, err
+}
 
-	value, ok := s.Records[key]
+// Fetch the value of a key and delete it.
+func (s *Store) GetDel(key string) ([]byte, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, ok, err := s.stringRecord(key)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
+		s.Stats.Misses.Add(1)
 		return nil, ErrKeyNotExists
 	}
-	return value.Data, nil
+	s.Stats.Hits.Add(1)
+	s.deleteRecord(key)
+	return value.Bytes(), nil
+}
+
+// Store a persistent value for a key and return its previous value.
+func (s *Store) GetSet(key string, data []byte) ([]byte, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, ok, err := s.stringRecord(key)
+	if err != nil {
+		return nil, err
+	}
+	s.putRecord(key, newValue(data))
+	if !ok {
+		return nil, ErrKeyNotExists
+	}
+	return value.Bytes(), nil
+}
+
+// Fetch the value of a key and set its expiration time, unless the
+// time is zero. If persist is set, the expiration time is removed
+// instead. A key set to expire in the past is removed.
+func (s *Store) GetEx(key string, expireAt time.Time, persist bool) ([]byte, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, ok, err := s.stringRecord(key)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.Stats.Misses.Add(1)
+		return nil, ErrKeyNotExists
+	}
+	s.Stats.Hits.Add(1)
+
+	switch {
+	case persist:
+		value.ExpireAt = 0
+		s.putRecord(key, value)
+	case expireAt.IsZero():
+	case !expireAt.After(time.Now()):
+		s.deleteRecord(key)
+	default:
+		value.ExpireAt = expireAt.UnixMilli()
+		s.putRecord(key, value)
+	}
+	return bytes.Clone(value.Bytes()), nil
 }
 
 // Fetch values of multiple keys at once.
-// If key does not exist, <nil> is appended as the value.
+// If key does not exist or does not hold a string, <nil> is appended
+// as the value.
 func (s *Store) MGet(keys []string) [][]byte {
+	values := make([][]byte, len(keys))
+	var expired []string
+
 	s.Mutex.RLock()
-	defer s.Mutex.RUnlock()
+	for i, key := range keys {
+		value, ok := s.Records[key]
+		if ok && value.expired() {
+			expired = append(expired, key)
+			ok = false
+		}
+		if !ok {
+			s.Stats.Misses.Add(1)
+			continue
+		}
+		s.Stats.Hits.Add(1)
+		if value.Type == TypeString {
+			values[i] = bytes.Clone(value.Bytes())
+		}
+	}
+	s.Mutex.RUnlock()
 
-	var values [][]byte
-	for _, key := range keys {
-		value, _ := s.Get(key)
-		values = append(values, value)
+	if len(expired) > 0 {
+		s.Mutex.Lock()
+		for _, key := range expired {
+			s.expireIfNeeded(key)
+		}
+		s.Mutex.Unlock()
 	}
 	return values
 }
 
+// A key and its value, written by MSet and MSetNX.
+type Pair struct {
+	Key   string
+	Value []byte
+}
+
+// Store multiple persistent key-value pairs at once, overwriting any
+// existing values. Readers never see only part of the pairs written.
+// If a key is given more than once, its last value is kept.
+func (s *Store) MSet(pairs []Pair) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	for _, pair := range pairs {
+		s.putRecord(pair.Key, newValue(pair.Value))
+	}
+}
+
+// Store multiple persistent key-value pairs at once, only if none of
+// the keys exist. Returns false if any key exists, in which case
+// nothing is written.
+func (s *Store) MSetNX(pairs []Pair) bool {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	for _, pair := range pairs {
+		s.expireIfNeeded(pair.Key)
+		if _, ok := s.Records[pair.Key]; ok {
+			return false
+		}
+	}
+	for _, pair := range pairs {
+		s.putRecord(pair.Key, newValue(pair.Value))
+	}
+	return true
+}
+
 // Delete a key-value pair.
 func (s *Store) Del(key string) error {
-	if !s.Exists(key) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	if _, ok := s.Records[key]; !ok || s.expireIfNeeded(key) {
 		return ErrKeyNotExists
 	}
+	s.deleteRecord(key)
+	return nil
+}
 
+// Rename a key, whatever the type of its value, along with its
+// expiration time. An existing destination key is overwritten unless
+// nx is set. Returns false if nx is set and the destination exists.
+func (s *Store) Rename(key, newKey string, nx bool) (bool, error) {
 	s.Mutex.Lock()
 	defer s.Mutex.Unlock()
 
-	delete(s.Records, key)
-	return nil
+	s.expireIfNeeded(key)
+	value, ok := s.Records[key]
+	if !ok {
+		return false, ErrKeyNotExists
+	}
+	if key == newKey {
+		return !nx, nil
+	}
+	s.expireIfNeeded(newKey)
+	if _, exists := s.Records[newKey]; exists && nx {
+		return false, nil
+	}
+	s.deleteRecord(key)
+	s.putRecord(newKey, value)
+	return true, nil
 }
 
-// Set or update the expiration time for a key-value pair.
-func (s *Store) Expire(key string, expiration time.Duration) error {
+// Copy the value of a key, whatever its type, along with its
+// expiration time. The copy shares nothing with the original. An
+// existing destination key is overwritten only if replace is set.
+// Returns false if nothing was copied.
+func (s *Store) Copy(key, newKey string, replace bool) bool {
 	s.Mutex.Lock()
 	defer s.Mutex.Unlock()
 
+	s.expireIfNeeded(key)
 	value, ok := s.Records[key]
 	if !ok {
-		return ErrKeyNotExists
+		return false
 	}
-	value.Expiration = expiration
-	s.Records[key] = value
-	return nil
+	s.expireIfNeeded(newKey)
+	if _, exists := s.Records[newKey]; exists && !replace {
+		return false
+	}
+
+	switch value.Type {
+	case TypeString:
+		// Strings are modified in place, so the copy needs its own
+		// backing array
+		value.Data = bytes.Clone(value.Data)
+	case TypeSet:
+		value.Set = value.Set.Clone()
+	case TypeZSet:
+		value.ZSet = value.ZSet.Clone()
+	}
+	s.putRecord(newKey, value)
+	return true
 }
 
-// Set or update a key-value pair to persist in store.
-func (s *Store) Persist(key string) error {
-	return s.Expire(key, -1)
+// Set the expiration time of a key to a Unix time in milliseconds,
+// if the conditions are met. A key set to expire in the past is
+// removed. Returns false if a condition is not met.
+//
+// A persistent key is treated as having an infinite time to live,
+// so ExpireGT always fails and ExpireLT always succeeds on it.
+func (s *Store) ExpireAt(key string, at int64, conditions int) (bool, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, ok := s.Records[key]
+	if !ok || s.expireIfNeeded(key) {
+		return false, ErrKeyNotExists
+	}
+	persistent := value.ExpireAt == 0
+	if (conditions&ExpireNX != 0 && !persistent) ||
+		(conditions&ExpireXX != 0 && persistent) ||
+		(conditions&ExpireGT != 0 && (persistent || at <= value.ExpireAt)) ||
+		(conditions&ExpireLT != 0 && !persistent && at >= value.ExpireAt) {
+		return false, nil
+	}
+
+	if at <= time.Now().UnixMilli() {
+		s.deleteRecord(key)
+		return true, nil
+	}
+	value.ExpireAt = at
+	s.putRecord(key, value)
+	return true, nil
+}
+
+// Set the expiration time of a key to a duration from now.
+func (s *Store) Expire(key string, expiration time.Duration) error {
+	_, err := s.ExpireAt(key, time.Now().Add(expiration).UnixMilli(), 0)
+	return err
 }
 
-// Fetch all keys in the store.
-func (s *Store) Keys() []string {
+// Remove the expiration time of a key, making it persistent.
+// Returns false if the key does not exist or has no expiration time.
+func (s *Store) Persist(key string) bool {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, ok := s.Records[key]
+	if !ok || s.expireIfNeeded(key) || value.ExpireAt == 0 {
+		return false
+	}
+	value.ExpireAt = 0
+	s.putRecord(key, value)
+	return true
+}
+
+// Fetch the keys of the store matching a glob style pattern. Expired
+// keys are skipped and left for the sweeper.
+func (s *Store) Keys(pattern string) []string {
 	s.Mutex.RLock()
 	defer s.Mutex.RUnlock()
 
 	var keys []string
-	for key := range s.Records {
-		keys = append(keys, key)
+	for key, value := range s.Records {
+		if !value.expired() && glob.Match(pattern, key) {
+			keys = append(keys, key)
+		}
 	}
 	return keys
 }
 
-// Returns the expiration time of a key as Unix timestamp.
+// Returns the expiration time of a key as a Unix time in
+// milliseconds, or 0 if the key is persistent.
 func (s *Store) ExpireTime(key string) (int64, error) {
-	s.Mutex.RLock()
-	defer s.Mutex.RUnlock()
-
-	value, ok := s.Records[key]
+	value, ok := s.lookup(key)
 	if !ok {
 		return 0, ErrKeyNotExists
 	}
-	expireTime := value.Timestamp.Add(value.Expiration)
-	return expireTime.Unix(), nil
+	return value.ExpireAt, nil
 }
 
-// Returns the expiration time in seconds of a key
-func (s *Store) TTL(key string) (float64, error) {
-	s.Mutex.RLock()
-	defer s.Mutex.RUnlock()
-
-	value, ok := s.Records[key]
-	if !ok {
-		return 0, ErrKeyNotExists
+// Returns the remaining time to live of a key, or -1 if the key
+// is persistent.
+func (s *Store) TTL(key string) (time.Duration, error) {
+	at, err := s.ExpireTime(key)
+	if err != nil {
+		return 0, err
+	}
+	if at == 0 {
+		return -1, nil
 	}
-	ttl := time.Until(value.Timestamp.Add(value.Expiration))
-	return ttl.Seconds(), nil
+	return max(time.Until(time.UnixMilli(at)), 0), nil
 }
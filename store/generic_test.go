@@ -0,0 +1,93 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetAndModify(t *testing.T) {
+	store := NewStore()
+	store.SetEx("key", []byte("a"), time.Hour)
+
+	if got, err := store.GetSet("key", []byte("b")); err != nil || string(got) != "a" {
+		t.Errorf("got %q, %v, wanted %q, nil", got, err, "a")
+	}
+	if ttl, _ := store.TTL("key"); ttl != -1 {
+		t.Errorf("got %v, wanted the key to be persistent", ttl)
+	}
+	if _, err := store.GetSet("missing", []byte("c")); err != ErrKeyNotExists || !store.Exists("missing") {
+		t.Errorf("got %v, wanted %v and the key to be set", err, ErrKeyNotExists)
+	}
+
+	if got, err := store.GetEx("key", time.Now().Add(time.Hour), false); err != nil || string(got) != "b" {
+		t.Errorf("got %q, %v, wanted %q, nil", got, err, "b")
+	}
+	if ttl, _ := store.TTL("key"); ttl <= 0 {
+		t.Errorf("got %v, wanted a positive time to live", ttl)
+	}
+	store.GetEx("key", time.Time{}, true)
+	if ttl, _ := store.TTL("key"); ttl != -1 {
+		t.Errorf("got %v, wanted the key to be persistent", ttl)
+	}
+	if got, _ := store.GetEx("key", time.Now().Add(-time.Second), false); string(got) != "b" || store.Exists("key") {
+		t.Errorf("got %q, wanted %q and the key to be removed", got, "b")
+	}
+
+	if got, err := store.GetDel("missing"); err != nil || string(got) != "c" {
+		t.Errorf("got %q, %v, wanted %q, nil", got, err, "c")
+	}
+	if _, err := store.GetDel("missing"); err != ErrKeyNotExists {
+		t.Errorf("got %v, wanted %v", err, ErrKeyNotExists)
+	}
+}
+
+func TestRenameAndCopy(t *testing.T) {
+	store := NewStore()
+	store.SetEx("string", []byte("a"), time.Hour)
+	store.SAdd("set", "a")
+	store.ZAdd("zset", "a")
+
+	if ok, err := store.Rename("string", "renamed", false); !ok || err != nil {
+		t.Errorf("got %t, %v, wanted true, nil", ok, err)
+	}
+	if store.Exists("string") {
+		t.Errorf("got the old key, wanted it to be removed")
+	}
+	if ttl, _ := store.TTL("renamed"); ttl <= 0 {
+		t.Errorf("got %v, wanted the expiration time to move with the key", ttl)
+	}
+	if _, err := store.Rename("string", "renamed", false); err != ErrKeyNotExists {
+		t.Errorf("got %v, wanted %v", err, ErrKeyNotExists)
+	}
+	// A key of another type is overwritten, unless nx is set
+	if ok, _ := store.Rename("renamed", "set", true); ok {
+		t.Errorf("got true, wanted false")
+	}
+	if ok, _ := store.Rename("set", "renamed", false); !ok {
+		t.Errorf("got false, wanted true")
+	}
+	if _, err := store.Get("renamed"); err != ErrWrongType {
+		t.Errorf("got %v, wanted %v", err, ErrWrongType)
+	}
+	if n, _ := store.SCard("renamed"); n != 1 {
+		t.Errorf("got %d, wanted %d", n, 1)
+	}
+
+	if !store.Copy("renamed", "copy", false) || store.Copy("zset", "copy", false) {
+		t.Errorf("got a copy overwriting its destination without replace")
+	}
+	store.SAdd("copy", "b")
+	if n, _ := store.SCard("renamed"); n != 1 {
+		t.Errorf("got %d, wanted %d", n, 1)
+	}
+	if !store.Copy("zset", "copy", true) {
+		t.Errorf("got false, wanted true")
+	}
+	store.ZAdd("copy", "b")
+	if n, _ := store.ZCard("zset"); n != 1 {
+		t.Errorf("got %d, wanted %d", n, 1)
+	}
+	if store.Copy("missing", "copy", true) {
+		t.Errorf("got true, wanted false")
+	}
+}
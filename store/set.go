@@ -11,17 +11,33 @@ var ErrSetNotExists = errors.New("the set does not exist")
 
 // Add an element to the set.
 // Initializes a new set if it does not exist.
-func (s *Store) SAdd(set, key string) {
+// Returns false if the element was already a member.
+func (s *Store) SAdd(set, key string) (bool, error) {
 	s.Mutex.Lock()
 	defer s.Mutex.Unlock()
 
-	value, ok := s.Sets[set]
+	s.expireIfNeeded(set)
+	value, ok, err := s.typedRecord(set, TypeSet)
+	if err != nil {
+		return false, err
+	}
 	// Initialize a new set if it does not exist
 	if !ok {
-		value = Set.NewSet()
+		value = Value{Type: TypeSet, Set: Set.NewSet()}
+		s.putRecord(set, value)
+	}
+	if value.Set.Exists(key) {
+		return false, nil
 	}
-	value.Add(key)
-	s.Sets[set] = value
+	value.Set.Add(key)
+	return true, nil
+}
+
+// Fetch a set which has not expired. Must be called with the mutex
+// held.
+func (s *Store) getSet(set string) (Set.Set, bool, error) {
+	value, ok, err := s.typedRecord(set, TypeSet)
+	return value.Set, ok, err
 }
 
 // Return the elements of a set as a slice.
@@ -30,7 +46,10 @@ func (s *Store) SMembers(set string) ([]string, error) {
 	defer s.Mutex.RUnlock()
 
 	var elements []string
-	value, ok := s.Sets[set]
+	value, ok, err := s.getSet(set)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
 		return nil, ErrSetNotExists
 	}
@@ -45,7 +64,10 @@ func (s *Store) SCard(set string) (int, error) {
 	s.Mutex.RLock()
 	defer s.Mutex.RUnlock()
 
-	value, ok := s.Sets[set]
+	value, ok, err := s.getSet(set)
+	if err != nil {
+		return 0, err
+	}
 	if !ok {
 		return 0, ErrSetNotExists
 	}
@@ -57,7 +79,10 @@ func (s *Store) SIsMember(set, key string) (bool, error) {
 	s.Mutex.RLock()
 	defer s.Mutex.RUnlock()
 
-	value, ok := s.Sets[set]
+	value, ok, err := s.getSet(set)
+	if err != nil {
+		return false, err
+	}
 	if !ok {
 		return false, ErrSetNotExists
 	}
@@ -72,11 +97,17 @@ func (s *Store) SDiff(s1, s2 string) ([]string, error) {
 	defer s.Mutex.RUnlock()
 
 	var elements []string
-	v1, ok := s.Sets[s1]
+	v1, ok, err := s.getSet(s1)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
 		return nil, fmt.Errorf("the set %s does not exist", s1)
 	}
-	v2, ok := s.Sets[s2]
+	v2, ok, err := s.getSet(s2)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
 		return nil, fmt.Errorf("the set %s does not exist", s2)
 	}
@@ -91,15 +122,18 @@ func (s *Store) SDiff(s1, s2 string) ([]string, error) {
 // Store the set difference (s1 - s2) in a new set s3.
 // If s3 does not exist, a new set is first created and
 // then the elements are stored.
-func (s *Store) SDiffStore(s1, s2, s3 string) error {
+// Returns the number of elements in the difference.
+func (s *Store) SDiffStore(s1, s2, s3 string) (int, error) {
 	elements, err := s.SDiff(s1, s2)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	for _, element := range elements {
-		s.SAdd(s3, element)
+		if _, err := s.SAdd(s3, element); err != nil {
+			return 0, err
+		}
 	}
-	return nil
+	return len(elements), nil
 }
 
 func (s *Store) SInter(s1, s2 string) ([]string, error) {
@@ -107,11 +141,17 @@ func (s *Store) SInter(s1, s2 string) ([]string, error) {
 	defer s.Mutex.RUnlock()
 
 	var elements []string
-	v1, ok := s.Sets[s1]
+	v1, ok, err := s.getSet(s1)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
 		return nil, fmt.Errorf("the set %s does not exist", s1)
 	}
-	v2, ok := s.Sets[s2]
+	v2, ok, err := s.getSet(s2)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
 		return nil, fmt.Errorf("the set %s does not exist", s2)
 	}
@@ -123,15 +163,17 @@ func (s *Store) SInter(s1, s2 string) ([]string, error) {
 	return elements, nil
 }
 
-func (s *Store) SInterStore(s1, s2, s3 string) error {
+func (s *Store) SInterStore(s1, s2, s3 string) (int, error) {
 	elements, err := s.SInter(s1, s2)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	for _, element := range elements {
-		s.SAdd(s3, element)
+		if _, err := s.SAdd(s3, element); err != nil {
+			return 0, err
+		}
 	}
-	return nil
+	return len(elements), nil
 }
 
 func (s *Store) SUnion(s1, s2 string) ([]string, error) {
@@ -139,11 +181,17 @@ func (s *Store) SUnion(s1, s2 string) ([]string, error) {
 	defer s.Mutex.RUnlock()
 
 	var elements []string
-	v1, ok := s.Sets[s1]
+	v1, ok, err := s.getSet(s1)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
 		return nil, fmt.Errorf("the set %s does not exist", s1)
 	}
-	v2, ok := s.Sets[s2]
+	v2, ok, err := s.getSet(s2)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
 		return nil, fmt.Errorf("the set %s does not exist", s2)
 	}
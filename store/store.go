@@ -2,38 +2,73 @@ package store
 
 import (
 	"sync"
-	"time"
-
-	"github.com/Devansh3712/tandb/set"
-	"github.com/Devansh3712/tandb/zset"
+	"sync/atomic"
 )
 
 type Store struct {
-	Mutex   *sync.RWMutex
+	Mutex *sync.RWMutex
+	// Every key of the keyspace, whatever the type of its value
 	Records map[string]Value
-	Sets    map[string]set.Set
-	ZSets   map[string]zset.ZSet
+	Stats   *Stats
+	// Expiration times of the records
+	expires *expiryIndex
+	// Number of records of each type
+	counts *[typeCount]int
+}
+
+// Counters of keyspace events, reported by INFO.
+type Stats struct {
+	Hits    atomic.Int64
+	Misses  atomic.Int64
+	Expired atomic.Int64
+	Evicted atomic.Int64
+	// Cycles of the expired keys sweeper and the time spent in
+	// them, in nanoseconds
+	Sweeps    atomic.Int64
+	SweepTime atomic.Int64
+}
+
+// Set every counter back to 0.
+func (s *Stats) Reset() {
+	s.Hits.Store(0)
+	s.Misses.Store(0)
+	s.Expired.Store(0)
+	s.Evicted.Store(0)
+	s.Sweeps.Store(0)
+	s.SweepTime.Store(0)
+}
+
+// Number of keys of each type and of keys with an expiration time.
+type KeyspaceInfo struct {
+	Strings int
+	Sets    int
+	ZSets   int
+	Expires int
+}
+
+func (k KeyspaceInfo) Keys() int {
+	return k.Strings + k.Sets + k.ZSets
 }
 
 func NewStore() Store {
 	return Store{
 		Mutex:   &sync.RWMutex{},
 		Records: make(map[string]Value),
-		Sets:    make(map[string]set.Set),
-		ZSets:   make(map[string]zset.ZSet),
+		Stats:   &Stats{},
+		expires: newExpiryIndex(),
+		counts:  &[typeCount]int{},
 	}
 }
 
-// Run a background job to check if any key has reached its expiration
-// time and remove it from the store.
-func (s *Store) CheckTTL() {
-	for {
-		time.Sleep(time.Second)
-
-		for key, value := range s.Records {
-			if value.expired() {
-				s.Del(key)
-			}
-		}
+// Count the keys of the store.
+func (s *Store) Info() KeyspaceInfo {
+	s.Mutex.RLock()
+	defer s.Mutex.RUnlock()
+
+	return KeyspaceInfo{
+		Strings: s.counts[TypeString],
+		Sets:    s.counts[TypeSet],
+		ZSets:   s.counts[TypeZSet],
+		Expires: s.expires.len(),
 	}
 }
@@ -0,0 +1,253 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNotInteger = errors.New("value is not an integer or out of range")
+	ErrNotFloat   = errors.New("value is not a valid float")
+	ErrOverflow   = errors.New("increment or decrement would overflow")
+	ErrNaN        = errors.New("increment would produce NaN or Infinity")
+
+	ErrStringTooLong = errors.New("string exceeds maximum allowed size (proto-max-bulk-len)")
+)
+
+// Maximum length of the text of an int64, with its sign.
+const maxIntLen = 20
+
+// Parse the text of an integer which would be formatted back to the
+// same text, so without a sign, spaces or leading zeros.
+func parseCanonicalInt(data []byte) (int64, bool) {
+	if len(data) == 0 || len(data) > maxIntLen {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || strconv.FormatInt(n, 10) != string(data) {
+		return 0, false
+	}
+	return n, true
+}
+
+// Returns the integer held by a value.
+func (v *Value) integer() (int64, error) {
+	if v.Encoding == EncodingInt {
+		return v.Int, nil
+	}
+	n, ok := parseCanonicalInt(v.Data)
+	if !ok {
+		return 0, ErrNotInteger
+	}
+	return n, nil
+}
+
+// Add a delta to the integer value of a key and return the new value.
+// A missing key is created with a value of 0 first, and the expiration
+// time of an existing key is kept.
+func (s *Store) IncrBy(key string, delta int64) (int64, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, exists, err := s.stringRecord(key)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	if exists {
+		if n, err = value.integer(); err != nil {
+			return 0, err
+		}
+	}
+	if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+		return 0, ErrOverflow
+	}
+	n += delta
+
+	s.putRecord(key, Value{Int: n, Encoding: EncodingInt, ExpireAt: value.ExpireAt})
+	return n, nil
+}
+
+// Precision of the floats of IncrByFloat, in bits. Redis computes
+// with long doubles, which have a 64 bit mantissa on x86.
+const longDoublePrec = 64
+
+// Parse a decimal float with an optional sign and exponent. Hex
+// floats, underscores, spaces, infinities and NaN are rejected, as
+// are values beyond the range of a float64.
+func parseFloat(s string) (*big.Float, error) {
+	for i := 0; i < len(s); i++ {
+		if !strings.ContainsRune("0123456789.eE+-", rune(s[i])) {
+			return nil, ErrNotFloat
+		}
+	}
+	f, _, err := big.ParseFloat(s, 10, longDoublePrec, big.ToNearestEven)
+	if err != nil {
+		return nil, ErrNotFloat
+	}
+	if v, _ := f.Float64(); math.IsInf(v, 0) {
+		return nil, ErrNotFloat
+	}
+	return f, nil
+}
+
+// Format a float with 17 significant digits, as Redis does with
+// %.17Lg, but always in positional notation and without trailing
+// zeros.
+func formatFloat(f *big.Float) string {
+	mantissa, exponent, _ := strings.Cut(f.Text('e', 16), "e")
+	negative := strings.HasPrefix(mantissa, "-")
+	digits := strings.Replace(strings.TrimPrefix(mantissa, "-"), ".", "", 1)
+	exp, _ := strconv.Atoi(exponent)
+
+	var text string
+	switch {
+	case exp < 0:
+		text = "0." + strings.Repeat("0", -exp-1) + digits
+	case exp+1 >= len(digits):
+		text = digits + strings.Repeat("0", exp+1-len(digits))
+	default:
+		text = digits[:exp+1] + "." + digits[exp+1:]
+	}
+	if strings.Contains(text, ".") {
+		text = strings.TrimRight(strings.TrimRight(text, "0"), ".")
+	}
+	if negative && text != "0" {
+		text = "-" + text
+	}
+	return text
+}
+
+// Add an increment to the floating point value of a key and return
+// the new value. The increment and the value are parsed and added as
+// long doubles. A missing key is created with a value of 0 first, and
+// the expiration time of an existing key is kept.
+func (s *Store) IncrByFloat(key, increment string) ([]byte, error) {
+	delta, err := parseFloat(increment)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, exists, err := s.stringRecord(key)
+	if err != nil {
+		return nil, err
+	}
+	f := new(big.Float).SetPrec(longDoublePrec).SetInt64(value.Int)
+	if exists && value.Encoding == EncodingRaw {
+		if f, err = parseFloat(string(value.Data)); err != nil {
+			return nil, err
+		}
+	}
+	f.Add(f, delta)
+	if v, _ := f.Float64(); math.IsInf(v, 0) {
+		return nil, ErrNaN
+	}
+
+	data := []byte(formatFloat(f))
+	record := newValue(data)
+	record.ExpireAt = value.ExpireAt
+	s.putRecord(key, record)
+	return bytes.Clone(data), nil
+}
+
+// Fetch the record of a string key for a command which modifies it.
+// Must be called with the mutex locked.
+func (s *Store) stringRecord(key string) (Value, bool, error) {
+	s.expireIfNeeded(key)
+	return s.typedRecord(key, TypeString)
+}
+
+// Fetch a copy of the value of a string key. A missing key has a nil
+// value.
+func (s *Store) GetString(key string) ([]byte, error) {
+	var value []byte
+	err := s.readString(key, func(data []byte) {
+		value = bytes.Clone(data)
+	})
+	if err == ErrKeyNotExists {
+		return nil, nil
+	}
+	return value, err
+}
+
+// Append data to the value of a key, creating it if it does not
+// exist, and return the new length of the value. Returns
+// ErrStringTooLong if the value would be longer than maxLen.
+func (s *Store) Append(key string, data []byte, maxLen int) (int, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, _, err := s.stringRecord(key)
+	if err != nil {
+		return 0, err
+	}
+	// The data of the value is not copied to measure it
+	if len(value.Bytes())+len(data) > maxLen {
+		return 0, ErrStringTooLong
+	}
+	value.Data = append(value.Bytes(), data...)
+	value.Int, value.Encoding = 0, EncodingRaw
+	s.putRecord(key, value)
+	return len(value.Data), nil
+}
+
+// Returns the length of the value of a key, 0 if it does not exist.
+func (s *Store) StrLen(key string) (int, error) {
+	length := 0
+	err := s.readString(key, func(data []byte) {
+		length = len(data)
+	})
+	if err == ErrKeyNotExists {
+		return 0, nil
+	}
+	return length, err
+}
+
+// Returns a copy of the part of the value of a key between two
+// offsets, both included. Negative offsets count from the end of the
+// value.
+func (s *Store) GetRange(key string, start, end int) ([]byte, error) {
+	value := []byte{}
+	err := s.readString(key, func(data []byte) {
+		if start, end, ok := clampRange(start, end, len(data)); ok {
+			value = bytes.Clone(data[start : end+1])
+		}
+	})
+	if err == ErrKeyNotExists {
+		return []byte{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Overwrite the value of a key from an offset, padding it with zero
+// bytes if it is shorter than the offset, and return the new length
+// of the value. An empty key is not created when data is empty.
+func (s *Store) SetRange(key string, offset int, data []byte) (int, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	value, _, err := s.stringRecord(key)
+	if err != nil {
+		return 0, err
+	}
+	current := value.Bytes()
+	if len(data) == 0 {
+		return len(current), nil
+	}
+
+	updated := extend(current, offset+len(data))
+	copy(updated[offset:], data)
+	value.Data, value.Int, value.Encoding = updated, 0, EncodingRaw
+	s.putRecord(key, value)
+	return len(updated), nil
+}
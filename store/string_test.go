@@ -0,0 +1,145 @@
+package store
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIncrBy(t *testing.T) {
+	store := NewStore()
+	store.SetEx("counter", []byte("10"), time.Hour)
+	if got := store.Records["counter"].Encoding; got != EncodingInt {
+		t.Errorf("got %d, wanted %d", got, EncodingInt)
+	}
+
+	n, err := store.IncrBy("counter", -15)
+	if err != nil || n != -5 {
+		t.Errorf("got %d, %v, wanted %d, nil", n, err, -5)
+	}
+	if at, _ := store.ExpireTime("counter"); at == 0 {
+		t.Errorf("got a persistent key, wanted its expiration time to be kept")
+	}
+	if n, _ := store.IncrBy("missing", 3); n != 3 {
+		t.Errorf("got %d, wanted %d", n, 3)
+	}
+
+	store.Set("max", []byte("9223372036854775807"))
+	if _, err := store.IncrBy("max", 1); err != ErrOverflow {
+		t.Errorf("got %v, wanted %v", err, ErrOverflow)
+	}
+	if _, err := store.IncrBy("counter", math.MinInt64); err != ErrOverflow {
+		t.Errorf("got %v, wanted %v", err, ErrOverflow)
+	}
+	// Text which is not the canonical form of an integer is kept raw
+	store.Set("padded", []byte("007"))
+	if _, err := store.IncrBy("padded", 1); err != ErrNotInteger {
+		t.Errorf("got %v, wanted %v", err, ErrNotInteger)
+	}
+}
+
+func TestIncrByFloat(t *testing.T) {
+	store := NewStore()
+	store.Set("value", []byte("1.5"))
+
+	if f, err := store.IncrByFloat("value", "1.5"); err != nil || string(f) != "3" {
+		t.Errorf("got %q, %v, wanted %q, nil", f, err, "3")
+	}
+	if got, _ := store.Get("value"); string(got) != "3" {
+		t.Errorf("got %q, wanted %q", got, "3")
+	}
+	max := strconv.FormatFloat(math.MaxFloat64, 'g', -1, 64)
+	if _, err := store.IncrByFloat("value", max); err != nil {
+		t.Errorf("got %v, wanted nil", err)
+	}
+	if _, err := store.IncrByFloat("value", max); err != ErrNaN {
+		t.Errorf("got %v, wanted %v", err, ErrNaN)
+	}
+
+	// Values are added as long doubles and rounded to 17 digits
+	sums := []struct {
+		increments []string
+		want       string
+	}{
+		{[]string{"0.1", "0.2"}, "0.3"},
+		{[]string{"10.5", "-0.5"}, "10"},
+		{[]string{"5.0e3", "2.0e-3"}, "5000.002"},
+		{[]string{"1e20", "1"}, "100000000000000000000"},
+		{[]string{"-0.0001", "0.00005"}, "-0.00005"},
+	}
+	for _, sum := range sums {
+		store.Del("sum")
+		var got []byte
+		for _, increment := range sum.increments {
+			got, _ = store.IncrByFloat("sum", increment)
+		}
+		if string(got) != sum.want {
+			t.Errorf("%v: got %q, wanted %q", sum.increments, got, sum.want)
+		}
+	}
+
+	for _, increment := range []string{"0x1p3", "1_0", " 1", "inf", "NaN", "1e400", "", "."} {
+		if _, err := store.IncrByFloat("sum", increment); err != ErrNotFloat {
+			t.Errorf("%q: got %v, wanted %v", increment, err, ErrNotFloat)
+		}
+	}
+	store.Set("hex", []byte("0x10"))
+	if _, err := store.IncrByFloat("hex", "1"); err != ErrNotFloat {
+		t.Errorf("got %v, wanted %v", err, ErrNotFloat)
+	}
+}
+
+func TestStringRange(t *testing.T) {
+	store := NewStore()
+	store.Set("key", []byte("Hello"))
+
+	if n, _ := store.Append("key", []byte(" World"), 11); n != 11 {
+		t.Errorf("got %d, wanted %d", n, 11)
+	}
+	if _, err := store.Append("key", []byte("!"), 11); err != ErrStringTooLong {
+		t.Errorf("got %v, wanted %v", err, ErrStringTooLong)
+	}
+	store.Set("number", []byte("1234"))
+	if _, err := store.Append("number", []byte("5"), 4); err != ErrStringTooLong {
+		t.Errorf("got %v, wanted %v", err, ErrStringTooLong)
+	}
+	ranges := []struct {
+		start, end int
+		want       string
+	}{
+		{0, 4, "Hello"},
+		{-3, -1, "rld"},
+		{0, -1, "Hello World"},
+		{-100, 100, "Hello World"},
+		{5, 2, ""},
+	}
+	for _, r := range ranges {
+		if got, _ := store.GetRange("key", r.start, r.end); string(got) != r.want {
+			t.Errorf("%d %d: got %q, wanted %q", r.start, r.end, got, r.want)
+		}
+	}
+
+	// A value read before SETRANGE is not modified by it
+	before, _ := store.Get("key")
+	if n, _ := store.SetRange("key", 6, []byte("Redis")); n != 11 {
+		t.Errorf("got %d, wanted %d", n, 11)
+	}
+	if string(before) != "Hello World" {
+		t.Errorf("got %q, wanted %q", before, "Hello World")
+	}
+	if n, _ := store.SetRange("padded", 3, []byte("a")); n != 4 {
+		t.Errorf("got %d, wanted %d", n, 4)
+	}
+	if got, _ := store.Get("padded"); string(got) != "\x00\x00\x00a" {
+		t.Errorf("got %q, wanted %q", got, "\x00\x00\x00a")
+	}
+	if n, _ := store.SetRange("empty", 3, nil); n != 0 || store.Exists("empty") {
+		t.Errorf("got %d, wanted %d and no key", n, 0)
+	}
+
+	store.SAdd("set", "a")
+	if _, err := store.StrLen("set"); err != ErrWrongType {
+		t.Errorf("got %v, wanted %v", err, ErrWrongType)
+	}
+}
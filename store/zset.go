@@ -2,23 +2,43 @@ package store
 
 import "github.com/Devansh3712/tandb/zset"
 
-func (s *Store) ZAdd(set, key string) {
+// Add an element to the sorted set.
+// Returns false if the element was already a member.
+func (s *Store) ZAdd(set, key string) (bool, error) {
 	s.Mutex.Lock()
 	defer s.Mutex.Unlock()
 
-	value, ok := s.ZSets[set]
+	s.expireIfNeeded(set)
+	value, ok, err := s.typedRecord(set, TypeZSet)
+	if err != nil {
+		return false, err
+	}
 	if !ok {
-		value = zset.NewZSet()
+		value = Value{Type: TypeZSet, ZSet: zset.NewZSet()}
+		s.putRecord(set, value)
+	}
+	if value.ZSet.Exists(key) {
+		return false, nil
 	}
-	value.Add(key)
-	s.ZSets[set] = value
+	value.ZSet.Add(key)
+	return true, nil
+}
+
+// Fetch a sorted set which has not expired. Must be called with the
+// mutex held.
+func (s *Store) getZSet(set string) (zset.ZSet, bool, error) {
+	value, ok, err := s.typedRecord(set, TypeZSet)
+	return value.ZSet, ok, err
 }
 
 func (s *Store) ZMembers(set string) ([]string, error) {
 	s.Mutex.RLock()
 	defer s.Mutex.RUnlock()
 
-	value, ok := s.ZSets[set]
+	value, ok, err := s.getZSet(set)
+	if err != nil {
+		return nil, err
+	}
 	if !ok {
 		return nil, ErrSetNotExists
 	}
@@ -29,7 +49,10 @@ func (s *Store) ZCard(set string) (int, error) {
 	s.Mutex.RLock()
 	defer s.Mutex.RUnlock()
 
-	value, ok := s.ZSets[set]
+	value, ok, err := s.getZSet(set)
+	if err != nil {
+		return 0, err
+	}
 	if !ok {
 		return 0, ErrSetNotExists
 	}
@@ -120,7 +120,7 @@ func (t *RBTree) fixInsert(node *Node) {
 				node.Parent.Color = BLACK
 				uncle.Color = BLACK
 				node.Parent.Parent.Color = RED
-				node = node.Parent
+				node = node.Parent.Parent
 			} else {
 				if node == node.Parent.Left {
 					node = node.Parent
@@ -319,3 +319,18 @@ func (t *RBTree) members() []string {
 	}
 	return result
 }
+
+// Copy a subtree, linking the copy of its root to a parent.
+func cloneNode(node, parent *Node) *Node {
+	if node == nil {
+		return nil
+	}
+	clone := &Node{Color: node.Color, Value: node.Value, Parent: parent}
+	clone.Left = cloneNode(node.Left, clone)
+	clone.Right = cloneNode(node.Right, clone)
+	return clone
+}
+
+func (t *RBTree) clone() *RBTree {
+	return &RBTree{Root: cloneNode(t.Root, nil), Count: t.Count}
+}
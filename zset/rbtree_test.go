@@ -25,6 +25,22 @@ func TestInsert(t *testing.T) {
 	}
 }
 
+func TestInsertSorted(t *testing.T) {
+	// Ascending values are all inserted on the right, repainting
+	// through the right uncle of each new node
+	tree := NewRBTree()
+	want := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	for _, member := range want {
+		tree.insert(member)
+	}
+	if got := tree.members(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+	if tree.Root.Color != BLACK || tree.Count != len(want) {
+		t.Errorf("got color %d and count %d, wanted a black root and %d", tree.Root.Color, tree.Count, len(want))
+	}
+}
+
 func TestDelete(t *testing.T) {
 	tree := createTestTree()
 	tree.delete("hello")
@@ -51,3 +67,21 @@ func TestSearch(t *testing.T) {
 		t.Errorf("got %t, wanted %t", got, want)
 	}
 }
+
+func TestClone(t *testing.T) {
+	tree := createTestTree()
+	clone := tree.clone()
+	clone.insert("world")
+	tree.delete("hello")
+
+	got := clone.members()
+	want := []string{"are", "hello", "how", "secctan", "world", "you"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+	got = tree.members()
+	want = []string{"are", "how", "secctan", "you"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
@@ -60,6 +60,17 @@ func (z *ZSet) Members() []string {
 	return z.Elements.members()
 }
 
+// Returns a deep copy of the sorted set, with its own mutex and tree.
+func (z *ZSet) Clone() ZSet {
+	z.Mutex.RLock()
+	defer z.Mutex.RUnlock()
+
+	return ZSet{
+		Mutex:    &sync.RWMutex{},
+		Elements: z.Elements.clone(),
+	}
+}
+
 func (z1 *ZSet) Union(z2 ZSet) ZSet {
 	elements := NewZSet()
 	for _, element := range z1.Members() {